@@ -2,11 +2,14 @@ package adaptlimit
 
 import (
 	"context"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/estavadormir/adaptlimit/config"
 	"github.com/estavadormir/adaptlimit/metrics"
+	"github.com/estavadormir/adaptlimit/predict"
 )
 
 type AdaptLimiter interface {
@@ -24,13 +27,24 @@ func New(cfg *config.Config) AdaptLimiter {
 		cfg = config.DefaultConfig()
 	}
 
+	//an injected collector belongs to the caller; the shared one is released on Close
+	collector, ownsCollector := cfg.Collector, false
+	if collector == nil {
+		collector, ownsCollector = metrics.Shared(cfg.MetricsInterval), true
+	}
+
 	l := &limiter{
 		config:         cfg,
-		metrics:        metrics.NewCollector(cfg.MetricsInterval),
+		metrics:        collector,
+		ownsMetrics:    ownsCollector,
 		limits:         make(map[string]*keyLimit),
 		adjustInterval: cfg.AdjustInterval,
 	}
 
+	if cfg.Prediction == config.PredictionGlobal {
+		l.forecaster = predict.NewForecaster(cfg.PredictionOptions...)
+	}
+
 	l.startAdjuster()
 
 	return l
@@ -38,28 +52,37 @@ func New(cfg *config.Config) AdaptLimiter {
 
 type limiter struct {
 	config         *config.Config
-	metrics        *metrics.Collector
+	metrics        metrics.Provider
+	ownsMetrics    bool
 	limits         map[string]*keyLimit
 	adjustInterval time.Duration
 	mu             sync.RWMutex
-	closed         bool
+	closed         atomic.Bool
+	forecaster     *predict.Forecaster
+	score          forecastScore
 	adjusterDone   chan struct{}
+	adjusterExited chan struct{}
 }
 
 type keyLimit struct {
-	tokens         float64
-	maxTokens      float64
-	refillRate     float64
-	lastRefill     time.Time
-	successCount   int64
-	failureCount   int64
-	responseTimeMs int64
-	requestCount   int64
-	mu             sync.Mutex
+	tokens       float64
+	maxTokens    float64
+	refillRate   float64
+	lastRefill   time.Time
+	successCount int64
+	failureCount int64
+	latency      *metrics.Sketch
+	requestCount int64
+	demandCount  int64
+	forecaster   *predict.Forecaster
+	score        forecastScore
+	tightenedFor int
+	restoreRate  float64
+	mu           sync.Mutex
 }
 
 func (l *limiter) Allow(key string) bool {
-	if l.closed {
+	if l.closed.Load() {
 		return false
 	}
 
@@ -69,6 +92,7 @@ func (l *limiter) Allow(key string) bool {
 	defer limit.mu.Unlock()
 
 	l.refillTokens(limit)
+	limit.demandCount++
 
 	if limit.tokens >= 1 {
 		limit.tokens--
@@ -94,7 +118,7 @@ func (l *limiter) Wait(ctx context.Context, key string) error {
 }
 
 func (l *limiter) Done(key string, success bool, responseTime time.Duration) {
-	if l.closed {
+	if l.closed.Load() {
 		return
 	}
 
@@ -109,19 +133,20 @@ func (l *limiter) Done(key string, success bool, responseTime time.Duration) {
 		limit.failureCount++
 	}
 
-	limit.responseTimeMs += responseTime.Milliseconds()
+	limit.latency.Add(responseTime.Seconds())
 }
 
 func (l *limiter) Close() error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	if l.closed {
+	if !l.closed.CompareAndSwap(false, true) {
 		return nil
 	}
 
-	l.closed = true
 	close(l.adjusterDone)
+	<-l.adjusterExited
+
+	if l.ownsMetrics {
+		l.metrics.Stop()
+	}
 	return nil
 }
 
@@ -147,6 +172,11 @@ func (l *limiter) getOrCreateLimit(key string) *keyLimit {
 		maxTokens:  float64(l.config.InitialLimit),
 		refillRate: float64(l.config.InitialLimit) / float64(l.config.Interval.Seconds()),
 		lastRefill: time.Now(),
+		latency:    metrics.NewSketch(0.01),
+	}
+
+	if l.config.Prediction == config.PredictionPerKey || l.detectsAnomalies() {
+		limit.forecaster = predict.NewForecaster(l.config.PredictionOptions...)
 	}
 
 	l.limits[key] = limit
@@ -164,8 +194,11 @@ func (l *limiter) refillTokens(limit *keyLimit) {
 
 func (l *limiter) startAdjuster() {
 	l.adjusterDone = make(chan struct{})
+	l.adjusterExited = make(chan struct{})
 
 	go func() {
+		defer close(l.adjusterExited)
+
 		ticker := time.NewTicker(l.adjustInterval)
 		defer ticker.Stop()
 
@@ -180,58 +213,104 @@ func (l *limiter) startAdjuster() {
 	}()
 }
 
-func (l *limiter) adjustLimits() {
-	cpuLoad := l.metrics.CPULoad()
-	memLoad := l.metrics.MemoryLoad()
+func (l *limiter) cpuLoad() float64 {
+	switch l.config.CPUSignal {
+	case config.CPUSignalProcess:
+		return l.metrics.View(metrics.ReadingProcessCPU, l.config.LoadView)
+	case config.CPUSignalSystem:
+		return l.metrics.View(metrics.ReadingSystemCPU, l.config.LoadView)
+	default:
+		return l.metrics.View(metrics.ReadingCPU, l.config.LoadView)
+	}
+}
 
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+// pressure returns the worst "some" avg10 stall share across CPU, memory and IO
+func (l *limiter) pressure() float64 {
+	worst := max(l.metrics.CPUPressure().Some.Avg10, l.metrics.MemoryPressure().Some.Avg10)
+	worst = max(worst, l.metrics.IOPressure().Some.Avg10)
+	return worst / 100
+}
 
-	for _, limit := range l.limits {
-		limit.mu.Lock()
+// signalFactor combines the configured custom signals, skipping any that report unhealthy
+func (l *limiter) signalFactor() float64 {
+	factor := 1.0
 
-		if limit.requestCount < 10 {
-			limit.mu.Unlock()
+	for _, signal := range l.config.Signals {
+		if signal.Source == nil || !signal.Source.Healthy() {
 			continue
 		}
 
-		total := limit.successCount + limit.failureCount
-		errorRate := 0.0
-		if total > 0 {
-			errorRate = float64(limit.failureCount) / float64(total)
+		value := signal.Source.Value()
+		if value > signal.HighThreshold {
+			factor *= math.Pow(0.8, signal.Weight)
+		} else if value < signal.LowThreshold {
+			factor *= math.Pow(1.1, signal.Weight)
 		}
+	}
 
-		avgResponseTime := 0.0
-		if total > 0 {
-			avgResponseTime = float64(limit.responseTimeMs) / float64(total)
-		}
+	return factor
+}
+
+// loadReadings are the process-wide inputs to adjustment, read once per tick
+type loadReadings struct {
+	cpu          float64
+	memory       float64
+	pressure     float64
+	runtime      metrics.RuntimeStats
+	signalFactor float64
+}
+
+func (l *limiter) adjustLimits() {
+	runtimeStats := l.metrics.Runtime()
+	load := loadReadings{
+		cpu:          l.cpuLoad(),
+		memory:       max(l.metrics.View(metrics.ReadingMemory, l.config.LoadView), runtimeStats.HeapLimitRatio),
+		pressure:     l.pressure(),
+		runtime:      runtimeStats,
+		signalFactor: l.signalFactor(),
+	}
+
+	var anomalies []keyAnomaly
+
+	l.mu.RLock()
+
+	growth, hasGrowth := l.predictGlobalGrowth()
+
+	for key, limit := range l.limits {
+		limit.mu.Lock()
 
-		adjustFactor := 1.0
+		predicted, hasPrediction := l.predictDemand(limit, growth, hasGrowth)
 
-		if cpuLoad > l.config.HighLoadThreshold {
-			adjustFactor *= 0.8 // Reduce by 20% under high CPU load
-		} else if cpuLoad < l.config.LowLoadThreshold {
-			adjustFactor *= 1.2 // Increase by 20% under low CPU load
+		anomaly, hasAnomaly := l.detectAnomaly(limit)
+		if hasAnomaly {
+			anomalies = append(anomalies, keyAnomaly{key: key, anomaly: anomaly})
 		}
+		spike := hasAnomaly && anomaly.Kind == predict.AnomalySpike
 
-		if memLoad > l.config.HighLoadThreshold {
-			adjustFactor *= 0.9 // Reduce by 10% under high memory load
+		if limit.requestCount < 10 && !hasPrediction && !spike && limit.tightenedFor == 0 {
+			limit.mu.Unlock()
+			continue
 		}
 
-		if errorRate > l.config.HighErrorThreshold {
-			adjustFactor *= 0.7 // Reduce by 30% under high error rate
-		} else if errorRate < l.config.LowErrorThreshold {
-			adjustFactor *= 1.1 // Increase by 10% under low error rate
+		newRefillRate := limit.refillRate
+		healthy := true
+
+		if limit.requestCount >= 10 {
+			adjustFactor := l.adjustFactor(limit, load)
+			newRefillRate *= adjustFactor
+			healthy = adjustFactor >= 1
+
+			limit.successCount = 0
+			limit.failureCount = 0
+			limit.latency.Reset()
+			limit.requestCount = 0
 		}
 
-		targetResponseTime := float64(l.config.TargetResponseTime.Milliseconds())
-		if avgResponseTime > 0 && targetResponseTime > 0 {
-			responseTimeFactor := targetResponseTime / avgResponseTime
-			responseTimeFactor = max(0.8, min(responseTimeFactor, 1.2))
-			adjustFactor *= responseTimeFactor
+		if hasPrediction {
+			newRefillRate = l.prescale(newRefillRate, predicted, healthy)
 		}
 
-		newRefillRate := limit.refillRate * adjustFactor
+		newRefillRate = l.tighten(limit, newRefillRate, spike)
 
 		minRate := float64(l.config.MinLimit) / float64(l.config.Interval.Seconds())
 		maxRate := float64(l.config.MaxLimit) / float64(l.config.Interval.Seconds())
@@ -240,13 +319,70 @@ func (l *limiter) adjustLimits() {
 		limit.refillRate = newRefillRate
 		limit.maxTokens = newRefillRate * float64(l.config.Interval.Seconds())
 
-		limit.successCount = 0
-		limit.failureCount = 0
-		limit.responseTimeMs = 0
-		limit.requestCount = 0
-
 		limit.mu.Unlock()
 	}
+
+	l.mu.RUnlock()
+
+	if l.config.OnAnomaly != nil {
+		for _, a := range anomalies {
+			l.config.OnAnomaly(a.key, a.anomaly)
+		}
+	}
+}
+
+func (l *limiter) adjustFactor(limit *keyLimit, load loadReadings) float64 {
+	total := limit.successCount + limit.failureCount
+	errorRate := 0.0
+	if total > 0 {
+		errorRate = float64(limit.failureCount) / float64(total)
+	}
+
+	responseTime := limit.latency.Mean()
+	if l.config.LatencyPercentile > 0 {
+		responseTime = limit.latency.Quantile(l.config.LatencyPercentile)
+	}
+
+	adjustFactor := 1.0
+
+	if load.cpu > l.config.HighLoadThreshold {
+		adjustFactor *= 0.8 // Reduce by 20% under high CPU load
+	} else if load.cpu < l.config.LowLoadThreshold {
+		adjustFactor *= 1.2 // Increase by 20% under low CPU load
+	}
+
+	if load.memory > l.config.HighLoadThreshold {
+		adjustFactor *= 0.9 // Reduce by 10% under high memory load
+	}
+
+	if l.config.HighPressureThreshold > 0 && load.pressure > l.config.HighPressureThreshold {
+		adjustFactor *= 0.8 // Reduce by 20% while tasks are stalling
+	}
+
+	if l.config.HighGCThreshold > 0 && load.runtime.GCCPUFraction > l.config.HighGCThreshold {
+		adjustFactor *= 0.9 // Reduce by 10% while the GC is busy
+	}
+
+	if l.config.MaxSchedulerLatency > 0 && load.runtime.SchedLatencyP99 > l.config.MaxSchedulerLatency {
+		adjustFactor *= 0.9 // Reduce by 10% while goroutines wait to be scheduled
+	}
+
+	adjustFactor *= load.signalFactor
+
+	if errorRate > l.config.HighErrorThreshold {
+		adjustFactor *= 0.7 // Reduce by 30% under high error rate
+	} else if errorRate < l.config.LowErrorThreshold {
+		adjustFactor *= 1.1 // Increase by 10% under low error rate
+	}
+
+	targetResponseTime := l.config.TargetResponseTime.Seconds()
+	if responseTime > 0 && targetResponseTime > 0 {
+		responseTimeFactor := targetResponseTime / responseTime
+		responseTimeFactor = max(0.8, min(responseTimeFactor, 1.2))
+		adjustFactor *= responseTimeFactor
+	}
+
+	return adjustFactor
 }
 
 func min(a, b float64) float64 {
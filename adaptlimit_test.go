@@ -1,10 +1,12 @@
 package adaptlimit
 
 import (
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/estavadormir/adaptlimit/config"
+	"github.com/estavadormir/adaptlimit/metrics"
 )
 
 func TestRateLimiterBasic(t *testing.T) {
@@ -93,3 +95,174 @@ func TestRateLimiterDone(t *testing.T) {
 		t.Errorf("Limit should have decreased below 10, but allowed %d requests", allowed)
 	}
 }
+
+func TestRateLimiterSignals(t *testing.T) {
+	saturated := metrics.NewSignal("queue-depth", func() float64 { return 1 }, nil)
+	broken := metrics.NewSignal("db-pool", func() float64 { return 1 }, func() bool { return false })
+
+	cfg := config.DefaultConfig().
+		WithInitialLimit(10).
+		WithMinLimit(1).
+		WithInterval(time.Millisecond*200).
+		WithAdjustInterval(time.Millisecond*100).
+		WithSignal(saturated, 0.2, 0.8, 5).
+		WithSignal(broken, 0.2, 0.8, 100)
+
+	limiter := New(cfg)
+	defer limiter.Close()
+
+	key := "test-key-signals"
+
+	for range 20 {
+		if limiter.Allow(key) {
+			limiter.Done(key, true, time.Millisecond)
+		}
+	}
+
+	//long enough for the adjuster to run and the bucket to refill
+	time.Sleep(time.Millisecond * 300)
+
+	allowed := 0
+	for range 20 {
+		if limiter.Allow(key) {
+			allowed++
+		}
+	}
+
+	if allowed == 0 || allowed >= 10 {
+		t.Errorf("A saturated signal should lower the limit without the unhealthy one zeroing it, allowed %d requests", allowed)
+	}
+}
+
+func TestRateLimiterFakeCollector(t *testing.T) {
+	fake := metrics.NewFake(metrics.Sample{CPU: 0.95, Memory: 0.5})
+
+	cfg := config.DefaultConfig().
+		WithInitialLimit(10).
+		WithMinLimit(1).
+		WithInterval(time.Millisecond * 200).
+		WithAdjustInterval(time.Millisecond * 100).
+		WithCollector(fake)
+
+	limiter := New(cfg)
+	defer limiter.Close()
+
+	drain := func(key string) int {
+		allowed := 0
+		for range 50 {
+			if limiter.Allow(key) {
+				limiter.Done(key, true, cfg.TargetResponseTime)
+				allowed++
+			}
+		}
+		return allowed
+	}
+
+	drain("busy")
+	time.Sleep(time.Millisecond * 300)
+
+	if allowed := drain("busy"); allowed >= 10 {
+		t.Errorf("High CPU load should lower the limit below 10, allowed %d requests", allowed)
+	}
+
+	fake.SetCPULoad(0.05)
+	drain("idle")
+	time.Sleep(time.Millisecond * 300)
+
+	if allowed := drain("idle"); allowed <= 10 {
+		t.Errorf("Low CPU load should raise the limit above 10, allowed %d requests", allowed)
+	}
+}
+
+func TestRateLimiterCloseReleasesGoroutines(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	limiters := make([]AdaptLimiter, 5)
+	for i := range limiters {
+		limiters[i] = New(config.DefaultConfig().WithAdjustInterval(time.Millisecond * 10))
+	}
+
+	//at most one adjuster per limiter plus a single shared collector; fewer if an earlier
+	//test's collector for the same interval is still being shared
+	if n := goroutinesAtMost(baseline + len(limiters) + 1); n > baseline+len(limiters)+1 {
+		t.Errorf("Expected at most %d goroutines with a shared collector, got %d", baseline+len(limiters)+1, n)
+	}
+
+	for _, limiter := range limiters {
+		limiter.Close()
+	}
+	limiters[0].Close()
+
+	if n := goroutinesAtMost(baseline); n > baseline {
+		t.Errorf("Goroutines leaked after Close: %d, expected %d", n, baseline)
+	}
+}
+
+// goroutinesAtMost polls until no more than limit goroutines run, since exiting ones
+// may still be counted briefly after signalling they are done
+func goroutinesAtMost(limit int) int {
+	deadline := time.Now().Add(time.Second)
+	for {
+		n := runtime.NumGoroutine()
+		if n <= limit || time.Now().After(deadline) {
+			return n
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestRateLimiterCloseKeepsInjectedCollector(t *testing.T) {
+	fake := metrics.NewFake(metrics.Sample{})
+
+	limiter := New(config.DefaultConfig().WithCollector(fake))
+	limiter.Close()
+
+	if fake.Stopped() {
+		t.Errorf("Close should not stop a collector owned by the caller")
+	}
+}
+
+func TestRateLimiterLatencyPercentile(t *testing.T) {
+	run := func(percentile float64) int {
+		cfg := config.DefaultConfig().
+			WithInitialLimit(100).
+			WithInterval(time.Millisecond * 200).
+			WithAdjustInterval(time.Millisecond * 200).
+			WithLatencyPercentile(percentile).
+			WithCollector(metrics.NewFake(metrics.Sample{CPU: 0.5, Memory: 0.5}))
+
+		limiter := New(cfg)
+		defer limiter.Close()
+
+		key := "test-key-latency"
+
+		//a slow tail that the mean hides
+		for i := range 100 {
+			if limiter.Allow(key) {
+				latency := time.Microsecond * 500
+				if i < 5 {
+					latency = time.Millisecond * 500
+				}
+				limiter.Done(key, true, latency)
+			}
+		}
+
+		time.Sleep(time.Millisecond * 350)
+
+		allowed := 0
+		for range 200 {
+			if limiter.Allow(key) {
+				allowed++
+			}
+		}
+		return allowed
+	}
+
+	if allowed := run(0); allowed <= 100 {
+		t.Errorf("With a fast mean the limit should grow above 100, allowed %d", allowed)
+	}
+
+	if allowed := run(0.99); allowed >= 100 {
+		t.Errorf("With a slow p99 the limit should shrink below 100, allowed %d", allowed)
+	}
+}
@@ -0,0 +1,47 @@
+package adaptlimit
+
+import (
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+type keyAnomaly struct {
+	key     string
+	anomaly predict.Anomaly
+}
+
+func (l *limiter) detectsAnomalies() bool {
+	return l.config.AnomalyFactor > 0 || l.config.OnAnomaly != nil
+}
+
+// detectAnomaly checks the demand just recorded for limit. It must be called with limit.mu held.
+func (l *limiter) detectAnomaly(limit *keyLimit) (predict.Anomaly, bool) {
+	if !l.detectsAnomalies() {
+		return predict.Anomaly{}, false
+	}
+	return limit.forecaster.Detect()
+}
+
+// tighten caps refillRate at AnomalyFactor of the pre-spike rate for AnomalyCooldown adjustments
+// after a spike, then restores it. It must be called with limit.mu held.
+func (l *limiter) tighten(limit *keyLimit, refillRate float64, spike bool) float64 {
+	if spike && l.config.AnomalyFactor > 0 {
+		if limit.tightenedFor == 0 {
+			limit.restoreRate = limit.refillRate
+		}
+		limit.tightenedFor = l.config.AnomalyCooldown
+		if limit.tightenedFor < 1 {
+			limit.tightenedFor = 1
+		}
+		return min(refillRate, limit.restoreRate*l.config.AnomalyFactor)
+	}
+
+	if limit.tightenedFor == 0 {
+		return refillRate
+	}
+
+	limit.tightenedFor--
+	if limit.tightenedFor == 0 {
+		return max(refillRate, limit.restoreRate)
+	}
+	return min(refillRate, limit.restoreRate*l.config.AnomalyFactor)
+}
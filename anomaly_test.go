@@ -0,0 +1,59 @@
+package adaptlimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/config"
+	"github.com/estavadormir/adaptlimit/metrics"
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+func TestRateLimiterAnomalyTightens(t *testing.T) {
+	var anomalies []predict.Anomaly
+
+	cfg := config.DefaultConfig().
+		WithInitialLimit(10).
+		WithMinLimit(1).
+		WithAdjustInterval(time.Second).
+		WithCollector(metrics.NewFake(metrics.Sample{CPU: 0.5, Memory: 0.5})).
+		WithAnomalyDetection(0.5, 2, func(key string, anomaly predict.Anomaly) {
+			if key != "scraper" {
+				t.Errorf("Expected anomalies for scraper, got %q", key)
+			}
+			anomalies = append(anomalies, anomaly)
+		})
+
+	l := New(cfg).(*limiter)
+	defer l.Close()
+
+	demand := func(n int) {
+		for range n {
+			l.Allow("scraper")
+		}
+		l.adjustLimits()
+	}
+
+	for range 12 {
+		demand(5)
+	}
+	before := l.refillRateOf("scraper")
+
+	demand(500)
+	if len(anomalies) != 1 || anomalies[0].Kind != predict.AnomalySpike {
+		t.Fatalf("Expected one spike, got %+v", anomalies)
+	}
+	if rate := l.refillRateOf("scraper"); rate > before*0.5 {
+		t.Errorf("Expected the rate to be halved from %.2f, got %.2f", before, rate)
+	}
+
+	demand(5)
+	if rate := l.refillRateOf("scraper"); rate > before*0.5 {
+		t.Errorf("Expected the rate to stay tightened during the cooldown, got %.2f", rate)
+	}
+
+	demand(5)
+	if rate := l.refillRateOf("scraper"); rate < before {
+		t.Errorf("Expected the rate to be restored to %.2f after the cooldown, got %.2f", before, rate)
+	}
+}
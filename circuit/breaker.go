@@ -1,7 +1,10 @@
 package circuit
 
 import (
+	"math"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,107 +14,217 @@ const (
 	StateClosed State = iota
 	StateOpen
 	StateHalfOpen
+	StateForcedOpen
+	StateForcedClosed
+	StateDisabled
+
+	numStates = iota
 )
 
+// StateChange describes a single transition of a breaker
+type StateChange struct {
+	From   State
+	To     State
+	Reason string
+	Time   time.Time
+}
+
+// Listener is called after the breaker lock is released, on the goroutine that caused the transition
+type Listener func(StateChange)
+
+type Metrics struct {
+	State           State
+	LastStateChange time.Time
+	Transitions     int64
+
+	//calls to Allow, by the state the breaker was in when answering
+	Calls    map[State]int64
+	Allowed  int64
+	Rejected int64
+
+	Successes int64
+	Failures  int64
+
+	//consecutive trips without a successful close, and how long the current one lasts
+	OpenCycles  int
+	OpenTimeout time.Duration
+}
+
 type Breaker struct {
 	failureThreshold int
 	resetTimeout     time.Duration
 	halfOpenMax      int
 
+	backoffMultiplier float64
+	maxResetTimeout   time.Duration
+	jitter            float64
+
+	rampWindow    time.Duration
+	rampSuccesses int
+	rampRatio     float64
+
 	failures        int
 	state           State
 	lastStateChange time.Time
 	halfOpenCount   int
+	openCycles      int
+	openTimeout     time.Duration
+
+	rampCredit     float64
+	probeSuccesses int
+	probeFailures  int
+
+	//unix nanoseconds of the last call, written under the read lock on the fast path
+	lastUsed atomic.Int64
+
+	listeners []Listener
+	pending   []StateChange
+
+	//the registry's Settings.OnStateChange, replaced when the breaker is reconfigured
+	settingsListener Listener
+
+	transitions int64
+	successes   int64
+	failuresAll int64
+
+	//updated atomically so closed breakers can answer under the read lock
+	calls    [numStates]atomic.Int64
+	allowed  atomic.Int64
+	rejected atomic.Int64
 
 	mu sync.RWMutex
 }
 
 func NewBreaker(failureThreshold int, resetTimeout time.Duration) *Breaker {
-	return &Breaker{
+	b := &Breaker{
 		failureThreshold: failureThreshold,
 		resetTimeout:     resetTimeout,
 		halfOpenMax:      1,
+		openTimeout:      resetTimeout,
 		state:            StateClosed,
 		lastStateChange:  time.Now(),
 	}
+	b.lastUsed.Store(time.Now().UnixNano())
+	return b
 }
 
 func (b *Breaker) Allow() bool {
+	now := time.Now()
+
+	//admitting states change nothing but the counters, so they share the read lock
 	b.mu.RLock()
+	if state := b.state; state == StateClosed || state == StateForcedClosed || state == StateDisabled {
+		b.record(state, true, now)
+		b.mu.RUnlock()
+		return true
+	}
+	b.mu.RUnlock()
 
-	now := time.Now()
+	b.mu.Lock()
+	allowed := b.allow(now)
+	b.record(b.state, allowed, now)
+
+	events, listeners := b.takePending()
+	b.mu.Unlock()
+
+	notify(events, listeners)
+	return allowed
+}
 
+// record counts a call answered in state; it needs at least the read lock
+func (b *Breaker) record(state State, allowed bool, now time.Time) {
+	b.lastUsed.Store(now.UnixNano())
+	b.calls[state].Add(1)
+	if allowed {
+		b.allowed.Add(1)
+	} else {
+		b.rejected.Add(1)
+	}
+}
+
+func (b *Breaker) allow(now time.Time) bool {
 	switch b.state {
-	case StateClosed:
-		b.mu.RUnlock()
+	case StateClosed, StateForcedClosed, StateDisabled:
 		return true
 	case StateOpen:
-		if now.Sub(b.lastStateChange) > b.resetTimeout {
-			b.mu.RUnlock()
-			b.mu.Lock()
-
-			if b.state == StateOpen && time.Now().Sub(b.lastStateChange) > b.resetTimeout {
-				b.setState(StateHalfOpen)
-				b.halfOpenCount = 0
-				b.mu.Unlock()
-				return true
-			}
-
-			result := b.state == StateClosed
-			b.mu.Unlock()
-			return result
+		if now.Sub(b.lastStateChange) > b.openTimeout {
+			b.setState(StateHalfOpen, "reset timeout elapsed")
+			b.halfOpenCount = 0
+			b.rampCredit = 0
+			b.probeSuccesses = 0
+			b.probeFailures = 0
+			return true
 		}
-		b.mu.RUnlock()
 		return false
 	case StateHalfOpen:
-		allowed := b.halfOpenCount < b.halfOpenMax
-		if allowed {
-			b.mu.RUnlock()
-			b.mu.Lock()
-			if b.state == StateHalfOpen && b.halfOpenCount < b.halfOpenMax {
-				b.halfOpenCount++
-				b.mu.Unlock()
-				return true
-			}
-			b.mu.Unlock()
-			return false
+		if b.rampWindow > 0 {
+			return b.rampAllow(now)
+		}
+		if b.halfOpenCount < b.halfOpenMax {
+			b.halfOpenCount++
+			return true
 		}
-		b.mu.RUnlock()
 		return false
 	default:
-		b.mu.RUnlock()
 		return false
 	}
 }
 
 func (b *Breaker) Success() {
 	b.mu.Lock()
-	defer b.mu.Unlock()
+
+	if b.state != StateDisabled {
+		b.successes++
+	}
+	b.lastUsed.Store(time.Now().UnixNano())
 
 	switch b.state {
 	case StateClosed:
 		b.failures = 0
 	case StateHalfOpen:
-		b.setState(StateClosed)
-		b.failures = 0
+		b.probeSuccesses++
+		if b.rampWindow > 0 {
+			b.evaluateRamp()
+			break
+		}
+		b.close("probe succeeded")
 	}
+
+	events, listeners := b.takePending()
+	b.mu.Unlock()
+
+	notify(events, listeners)
 }
 
 func (b *Breaker) Failure() {
 	b.mu.Lock()
-	defer b.mu.Unlock()
+
+	if b.state != StateDisabled {
+		b.failuresAll++
+	}
+	b.lastUsed.Store(time.Now().UnixNano())
 
 	switch b.state {
 	case StateClosed:
 		b.failures++
 		if b.failures >= b.failureThreshold {
-			b.setState(StateOpen)
+			b.trip("failure threshold reached")
 		}
 	case StateHalfOpen:
-		b.setState(StateOpen)
+		b.probeFailures++
+		if b.rampWindow > 0 {
+			b.evaluateRamp()
+			break
+		}
+		b.trip("probe failed")
 	case StateOpen:
 		b.lastStateChange = time.Now()
 	}
+
+	events, listeners := b.takePending()
+	b.mu.Unlock()
+
+	notify(events, listeners)
 }
 
 func (b *Breaker) State() State {
@@ -120,9 +233,180 @@ func (b *Breaker) State() State {
 	return b.state
 }
 
-func (b *Breaker) setState(state State) {
+func (b *Breaker) Metrics() Metrics {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	calls := make(map[State]int64)
+	for state := range b.calls {
+		if n := b.calls[state].Load(); n > 0 {
+			calls[State(state)] = n
+		}
+	}
+
+	return Metrics{
+		State:           b.state,
+		LastStateChange: b.lastStateChange,
+		Transitions:     b.transitions,
+		Calls:           calls,
+		Allowed:         b.allowed.Load(),
+		Rejected:        b.rejected.Load(),
+		Successes:       b.successes,
+		Failures:        b.failuresAll,
+		OpenCycles:      b.openCycles,
+		OpenTimeout:     b.openTimeout,
+	}
+}
+
+// ForceOpen rejects every call until Reset, regardless of outcomes or timeouts
+func (b *Breaker) ForceOpen() {
+	b.override(StateForcedOpen, "forced open")
+}
+
+// ForceClosed admits every call until Reset; outcomes are still counted but never trip the breaker
+func (b *Breaker) ForceClosed() {
+	b.override(StateForcedClosed, "forced closed")
+}
+
+// Disable bypasses the breaker until Reset; calls are admitted and outcomes are not recorded
+func (b *Breaker) Disable() {
+	b.override(StateDisabled, "disabled")
+}
+
+// Reset clears any override, failure count, backoff and ramp progress and closes the breaker
+func (b *Breaker) Reset() {
+	b.mu.Lock()
+	b.halfOpenCount = 0
+	b.probeSuccesses = 0
+	b.probeFailures = 0
+	b.close("reset")
+	events, listeners := b.takePending()
+	b.mu.Unlock()
+
+	notify(events, listeners)
+}
+
+func (b *Breaker) override(state State, reason string) {
+	b.mu.Lock()
+	b.setState(state, reason)
+	events, listeners := b.takePending()
+	b.mu.Unlock()
+
+	notify(events, listeners)
+}
+
+// idle reports whether the breaker is closed and unused for maxIdle
+func (b *Breaker) idle(now time.Time, maxIdle time.Duration) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.state == StateClosed && now.Sub(time.Unix(0, b.lastUsed.Load())) >= maxIdle
+}
+
+// OnStateChange registers a listener for every future transition
+func (b *Breaker) OnStateChange(listener Listener) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, listener)
+	return b
+}
+
+// rampAllow admits a share of half-open traffic that grows linearly over the ramp window
+func (b *Breaker) rampAllow(now time.Time) bool {
+	fraction := float64(now.Sub(b.lastStateChange)) / float64(b.rampWindow)
+	b.rampCredit += max(0, min(fraction, 1))
+
+	if b.rampCredit >= 1 {
+		b.rampCredit--
+		return true
+	}
+	return false
+}
+
+func (b *Breaker) evaluateRamp() {
+	total := b.probeSuccesses + b.probeFailures
+	ratio := float64(b.probeSuccesses) / float64(total)
+
+	if b.probeSuccesses >= b.rampSuccesses && ratio >= b.rampRatio {
+		b.close("ramp succeeded")
+	} else if total >= b.rampSuccesses && ratio < b.rampRatio {
+		b.trip("ramp success ratio too low")
+	}
+}
+
+func (b *Breaker) close(reason string) {
+	b.setState(StateClosed, reason)
+	b.failures = 0
+	b.openCycles = 0
+	b.openTimeout = b.resetTimeout
+}
+
+// trip opens the breaker, backing off the open duration for every consecutive cycle
+func (b *Breaker) trip(reason string) {
+	b.openCycles++
+	b.openTimeout = b.nextOpenTimeout()
+	b.setState(StateOpen, reason)
+}
+
+func (b *Breaker) nextOpenTimeout() time.Duration {
+	timeout := float64(b.resetTimeout)
+	if b.backoffMultiplier > 1 {
+		timeout *= math.Pow(b.backoffMultiplier, float64(b.openCycles-1))
+	}
+
+	if b.jitter > 0 {
+		timeout *= 1 + b.jitter*(2*rand.Float64()-1) // Spread by +/- jitter
+	}
+
+	//capped last so jitter never pushes past the maximum
+	if b.maxResetTimeout > 0 {
+		timeout = math.Min(timeout, float64(b.maxResetTimeout))
+	}
+
+	return time.Duration(timeout)
+}
+
+func (b *Breaker) setState(state State, reason string) {
+	if b.state == state {
+		return
+	}
+
+	now := time.Now()
+	if len(b.listeners) > 0 || b.settingsListener != nil {
+		b.pending = append(b.pending, StateChange{
+			From:   b.state,
+			To:     state,
+			Reason: reason,
+			Time:   now,
+		})
+	}
+
 	b.state = state
-	b.lastStateChange = time.Now()
+	b.lastStateChange = now
+	b.transitions++
+}
+
+// takePending must be called with b.mu held; the result is handed to notify once it is released
+func (b *Breaker) takePending() ([]StateChange, []Listener) {
+	if len(b.pending) == 0 {
+		return nil, nil
+	}
+
+	events := b.pending
+	b.pending = nil
+
+	listeners := b.listeners
+	if b.settingsListener != nil {
+		listeners = append([]Listener{b.settingsListener}, listeners...)
+	}
+	return events, listeners
+}
+
+func notify(events []StateChange, listeners []Listener) {
+	for _, event := range events {
+		for _, listener := range listeners {
+			listener(event)
+		}
+	}
 }
 
 func (b *Breaker) WithHalfOpenMax(max int) *Breaker {
@@ -132,6 +416,29 @@ func (b *Breaker) WithHalfOpenMax(max int) *Breaker {
 	return b
 }
 
+// WithBackoff grows the open duration by multiplier for each consecutive trip, randomised
+// by +/- jitter (0.0-1.0) and never above maxTimeout. It resets once the breaker closes.
+func (b *Breaker) WithBackoff(multiplier float64, maxTimeout time.Duration, jitter float64) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.backoffMultiplier = multiplier
+	b.maxResetTimeout = maxTimeout
+	b.jitter = max(0, min(jitter, 1))
+	return b
+}
+
+// WithRamp replaces the fixed half-open probe budget with a share of traffic that grows
+// from 0 to 100% over window. The breaker closes once requiredSuccesses probes have
+// succeeded with at least successRatio (0.0-1.0), and reopens if the ratio falls short.
+func (b *Breaker) WithRamp(window time.Duration, requiredSuccesses int, successRatio float64) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rampWindow = window
+	b.rampSuccesses = max(1, requiredSuccesses)
+	b.rampRatio = successRatio
+	return b
+}
+
 func (s State) String() string {
 	switch s {
 	case StateClosed:
@@ -140,6 +447,12 @@ func (s State) String() string {
 		return "OPEN"
 	case StateHalfOpen:
 		return "HALF-OPEN"
+	case StateForcedOpen:
+		return "FORCED-OPEN"
+	case StateForcedClosed:
+		return "FORCED-CLOSED"
+	case StateDisabled:
+		return "DISABLED"
 	default:
 		return "UNKNOWN"
 	}
@@ -35,3 +35,231 @@ func TestCircuitBreakerStates(t *testing.T) {
 		t.Errorf("State after success in half-open should be CLOSED, got %v", breaker.State())
 	}
 }
+
+func TestCircuitBreakerListenersAndMetrics(t *testing.T) {
+	breaker := circuit.NewBreaker(1, time.Millisecond*50)
+
+	var changes []circuit.StateChange
+	breaker.OnStateChange(func(change circuit.StateChange) {
+		//listeners run outside the lock, so reading state back must not deadlock
+		if breaker.State() != change.To {
+			t.Errorf("Listener saw state %v, expected %v", breaker.State(), change.To)
+		}
+		changes = append(changes, change)
+	})
+
+	breaker.Allow()
+	breaker.Failure()
+
+	if breaker.Allow() {
+		t.Errorf("Request should be rejected while OPEN")
+	}
+
+	time.Sleep(time.Millisecond * 70)
+
+	breaker.Allow()
+	breaker.Success()
+
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 transitions, got %d", len(changes))
+	}
+
+	expected := []struct{ from, to circuit.State }{
+		{circuit.StateClosed, circuit.StateOpen},
+		{circuit.StateOpen, circuit.StateHalfOpen},
+		{circuit.StateHalfOpen, circuit.StateClosed},
+	}
+	for i, e := range expected {
+		if changes[i].From != e.from || changes[i].To != e.to {
+			t.Errorf("Transition %d should be %v -> %v, got %v -> %v", i, e.from, e.to, changes[i].From, changes[i].To)
+		}
+		if changes[i].Reason == "" {
+			t.Errorf("Transition %d should have a reason", i)
+		}
+	}
+
+	m := breaker.Metrics()
+	if m.Allowed != 2 || m.Rejected != 1 {
+		t.Errorf("Expected 2 allowed and 1 rejected, got %d and %d", m.Allowed, m.Rejected)
+	}
+	if m.Calls[circuit.StateClosed] != 1 || m.Calls[circuit.StateOpen] != 1 || m.Calls[circuit.StateHalfOpen] != 1 {
+		t.Errorf("Unexpected calls per state: %v", m.Calls)
+	}
+	if m.Successes != 1 || m.Failures != 1 || m.Transitions != 3 {
+		t.Errorf("Unexpected outcome counters: %+v", m)
+	}
+}
+
+func TestCircuitBreakerBackoff(t *testing.T) {
+	breaker := circuit.NewBreaker(1, time.Millisecond*20).
+		WithBackoff(2, time.Millisecond*60, 0)
+
+	breaker.Failure()
+	if got := breaker.Metrics().OpenTimeout; got != time.Millisecond*20 {
+		t.Errorf("First open cycle should use the reset timeout, got %v", got)
+	}
+
+	expected := []time.Duration{time.Millisecond * 40, time.Millisecond * 60, time.Millisecond * 60}
+	for i, want := range expected {
+		time.Sleep(breaker.Metrics().OpenTimeout + time.Millisecond*10)
+		if !breaker.Allow() {
+			t.Fatalf("Probe %d should be allowed after the open timeout", i)
+		}
+		breaker.Failure()
+
+		m := breaker.Metrics()
+		if m.OpenTimeout != want {
+			t.Errorf("Open cycle %d should last %v, got %v", m.OpenCycles, want, m.OpenTimeout)
+		}
+	}
+
+	time.Sleep(breaker.Metrics().OpenTimeout + time.Millisecond*10)
+	breaker.Allow()
+	breaker.Success()
+
+	m := breaker.Metrics()
+	if m.OpenCycles != 0 || m.OpenTimeout != time.Millisecond*20 {
+		t.Errorf("Backoff should reset after closing, got %d cycles and %v", m.OpenCycles, m.OpenTimeout)
+	}
+}
+
+func TestCircuitBreakerBackoffJitter(t *testing.T) {
+	breaker := circuit.NewBreaker(1, time.Second).
+		WithBackoff(2, 0, 0.5)
+
+	breaker.Failure()
+
+	got := breaker.Metrics().OpenTimeout
+	if got < time.Millisecond*500 || got > time.Millisecond*1500 {
+		t.Errorf("Jittered open timeout should be within 50%% of 1s, got %v", got)
+	}
+}
+
+func TestCircuitBreakerBackoffJitterCapped(t *testing.T) {
+	for range 50 {
+		breaker := circuit.NewBreaker(1, time.Second).
+			WithBackoff(2, time.Second, 0.5)
+
+		breaker.Failure()
+
+		if got := breaker.Metrics().OpenTimeout; got > time.Second {
+			t.Fatalf("Jitter should never push the open timeout past the 1s maximum, got %v", got)
+		}
+	}
+}
+
+func TestCircuitBreakerRamp(t *testing.T) {
+	breaker := circuit.NewBreaker(1, time.Millisecond*10).
+		WithRamp(time.Millisecond*200, 5, 0.8)
+
+	breaker.Failure()
+	time.Sleep(time.Millisecond * 20)
+
+	if !breaker.Allow() {
+		t.Fatalf("First probe after timeout should be allowed")
+	}
+
+	early := 0
+	for range 100 {
+		if breaker.Allow() {
+			early++
+		}
+	}
+	if early > 50 {
+		t.Errorf("Early in the ramp most requests should be rejected, allowed %d of 100", early)
+	}
+
+	for range 4 {
+		breaker.Success()
+	}
+	if breaker.State() != circuit.StateHalfOpen {
+		t.Errorf("Breaker should stay HALF-OPEN until enough probes succeed, got %v", breaker.State())
+	}
+
+	time.Sleep(time.Millisecond * 200)
+
+	late := 0
+	for range 10 {
+		if breaker.Allow() {
+			late++
+		}
+	}
+	if late != 10 {
+		t.Errorf("At the end of the ramp all requests should be allowed, allowed %d of 10", late)
+	}
+
+	breaker.Success()
+	if breaker.State() != circuit.StateClosed {
+		t.Errorf("Breaker should close after 5 successful probes, got %v", breaker.State())
+	}
+}
+
+func TestCircuitBreakerRampReopens(t *testing.T) {
+	breaker := circuit.NewBreaker(1, time.Millisecond*10).
+		WithRamp(time.Millisecond*50, 4, 0.75)
+
+	breaker.Failure()
+	time.Sleep(time.Millisecond * 20)
+	breaker.Allow()
+
+	breaker.Success()
+	breaker.Failure()
+	breaker.Success()
+	if breaker.State() != circuit.StateHalfOpen {
+		t.Fatalf("Breaker should stay HALF-OPEN before the required probes, got %v", breaker.State())
+	}
+
+	breaker.Failure()
+	if breaker.State() != circuit.StateOpen {
+		t.Errorf("Breaker should reopen when the success ratio is too low, got %v", breaker.State())
+	}
+}
+
+func TestCircuitBreakerOverrides(t *testing.T) {
+	breaker := circuit.NewBreaker(1, time.Millisecond*10)
+
+	breaker.ForceOpen()
+	time.Sleep(time.Millisecond * 20)
+	if breaker.Allow() {
+		t.Errorf("FORCED-OPEN breaker should reject calls after the reset timeout")
+	}
+	breaker.Success()
+	if breaker.State() != circuit.StateForcedOpen {
+		t.Errorf("Success should not leave FORCED-OPEN, got %v", breaker.State())
+	}
+
+	breaker.ForceClosed()
+	breaker.Failure()
+	breaker.Failure()
+	if !breaker.Allow() || breaker.State() != circuit.StateForcedClosed {
+		t.Errorf("FORCED-CLOSED breaker should admit calls despite failures, got %v", breaker.State())
+	}
+	if breaker.Metrics().Failures != 2 {
+		t.Errorf("FORCED-CLOSED breaker should still count failures, got %d", breaker.Metrics().Failures)
+	}
+
+	breaker.Disable()
+	breaker.Failure()
+	if !breaker.Allow() || breaker.Metrics().Failures != 2 {
+		t.Errorf("DISABLED breaker should admit calls without recording outcomes")
+	}
+
+	breaker.Reset()
+	if breaker.State() != circuit.StateClosed {
+		t.Errorf("Reset should close the breaker, got %v", breaker.State())
+	}
+	breaker.Failure()
+	if breaker.State() != circuit.StateOpen {
+		t.Errorf("Breaker should trip normally after Reset, got %v", breaker.State())
+	}
+
+	for state, want := range map[circuit.State]string{
+		circuit.StateForcedOpen:   "FORCED-OPEN",
+		circuit.StateForcedClosed: "FORCED-CLOSED",
+		circuit.StateDisabled:     "DISABLED",
+	} {
+		if state.String() != want {
+			t.Errorf("Expected %s, got %s", want, state.String())
+		}
+	}
+}
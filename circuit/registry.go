@@ -0,0 +1,229 @@
+package circuit
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type Settings struct {
+	//consecutive failures before the breaker opens
+	FailureThreshold int
+
+	//how long the breaker stays open before probing
+	ResetTimeout time.Duration
+
+	//probes admitted while half-open
+	HalfOpenMax int
+
+	//growth of the open duration per consecutive trip, see WithBackoff (0 disables)
+	BackoffMultiplier float64
+
+	//the cap for the backed off open duration
+	MaxResetTimeout time.Duration
+
+	//the random spread applied to the open duration (0.0-1.0)
+	Jitter float64
+
+	//the half-open ramp, see WithRamp (0 disables)
+	RampWindow time.Duration
+
+	//successful probes needed to close while ramping
+	RampSuccesses int
+
+	//the success ratio needed to close while ramping (0.0-1.0)
+	RampSuccessRatio float64
+
+	//called for every transition of any breaker built from these settings
+	OnStateChange func(name string, change StateChange)
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		FailureThreshold: 5,
+		ResetTimeout:     time.Second * 30,
+		HalfOpenMax:      1,
+	}
+}
+
+func (s Settings) build(name string) *Breaker {
+	b := NewBreaker(s.FailureThreshold, s.ResetTimeout)
+	b.apply(name, s)
+	return b
+}
+
+// apply swaps in new settings without touching the state, so overrides and counters survive.
+// An open breaker keeps its current open duration; the new settings apply from the next trip.
+func (b *Breaker) apply(name string, s Settings) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failureThreshold = s.FailureThreshold
+	b.resetTimeout = s.ResetTimeout
+
+	b.halfOpenMax = 1
+	if s.HalfOpenMax > 0 {
+		b.halfOpenMax = s.HalfOpenMax
+	}
+
+	b.backoffMultiplier = s.BackoffMultiplier
+	b.maxResetTimeout = s.MaxResetTimeout
+	b.jitter = max(0, min(s.Jitter, 1))
+
+	b.rampWindow = 0
+	if s.RampWindow > 0 {
+		b.rampWindow = s.RampWindow
+		b.rampSuccesses = max(1, s.RampSuccesses)
+		b.rampRatio = s.RampSuccessRatio
+	}
+
+	if b.state != StateOpen {
+		b.openTimeout = b.resetTimeout
+	}
+
+	b.settingsListener = nil
+	if s.OnStateChange != nil {
+		b.settingsListener = func(change StateChange) {
+			s.OnStateChange(name, change)
+		}
+	}
+}
+
+// Registry lazily creates and tracks breakers by dependency name
+type Registry struct {
+	defaults Settings
+	settings map[string]Settings
+	breakers map[string]*Breaker
+	mu       sync.RWMutex
+}
+
+func NewRegistry(defaults Settings) *Registry {
+	return &Registry{
+		defaults: defaults,
+		settings: make(map[string]Settings),
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// Configure sets the settings used for name. A breaker already created for it is updated
+// in place, keeping its state, any operator override and its metrics.
+func (r *Registry) Configure(name string, settings Settings) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings[name] = settings
+	if b, ok := r.breakers[name]; ok {
+		b.apply(name, settings)
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) *Breaker {
+	r.mu.RLock()
+	b, ok := r.breakers[name]
+	r.mu.RUnlock()
+
+	if ok {
+		return b
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok = r.breakers[name]
+	if ok {
+		return b
+	}
+
+	settings, ok := r.settings[name]
+	if !ok {
+		settings = r.defaults
+	}
+
+	b = settings.build(name)
+	r.breakers[name] = b
+	return b
+}
+
+// Names returns the names of all live breakers in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.breakers))
+	for name := range r.breakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Range calls fn for each live breaker until it returns false
+func (r *Registry) Range(fn func(name string, b *Breaker) bool) {
+	for _, name := range r.Names() {
+		r.mu.RLock()
+		b, ok := r.breakers[name]
+		r.mu.RUnlock()
+
+		if ok && !fn(name, b) {
+			return
+		}
+	}
+}
+
+func (r *Registry) States() map[string]State {
+	states := make(map[string]State)
+	r.Range(func(name string, b *Breaker) bool {
+		states[name] = b.State()
+		return true
+	})
+	return states
+}
+
+func (r *Registry) Snapshot() map[string]Metrics {
+	snapshot := make(map[string]Metrics)
+	r.Range(func(name string, b *Breaker) bool {
+		snapshot[name] = b.Metrics()
+		return true
+	})
+	return snapshot
+}
+
+func (r *Registry) ForceOpen(name string) {
+	r.Get(name).ForceOpen()
+}
+
+func (r *Registry) ForceClosed(name string) {
+	r.Get(name).ForceClosed()
+}
+
+func (r *Registry) Disable(name string) {
+	r.Get(name).Disable()
+}
+
+func (r *Registry) Reset(name string) {
+	r.Get(name).Reset()
+}
+
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.breakers, name)
+}
+
+// Evict drops closed breakers unused for maxIdle and returns how many were removed
+func (r *Registry) Evict(maxIdle time.Duration) int {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	evicted := 0
+	for name, b := range r.breakers {
+		if b.idle(now, maxIdle) {
+			delete(r.breakers, name)
+			evicted++
+		}
+	}
+	return evicted
+}
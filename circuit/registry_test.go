@@ -0,0 +1,123 @@
+package circuit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/circuit"
+)
+
+func TestRegistryLazyCreation(t *testing.T) {
+	registry := circuit.NewRegistry(circuit.DefaultSettings()).
+		Configure("payments", circuit.Settings{FailureThreshold: 1, ResetTimeout: time.Minute})
+
+	if registry.Get("users") != registry.Get("users") {
+		t.Errorf("Get should return the same breaker for the same name")
+	}
+
+	registry.Get("payments").Failure()
+	registry.Get("users").Failure()
+
+	states := registry.States()
+	if states["payments"] != circuit.StateOpen {
+		t.Errorf("payments should use its own threshold and be OPEN, got %v", states["payments"])
+	}
+	if states["users"] != circuit.StateClosed {
+		t.Errorf("users should use the default threshold and stay CLOSED, got %v", states["users"])
+	}
+
+	names := registry.Names()
+	if len(names) != 2 || names[0] != "payments" || names[1] != "users" {
+		t.Errorf("Expected sorted names [payments users], got %v", names)
+	}
+
+	if m := registry.Snapshot()["users"]; m.Failures != 1 {
+		t.Errorf("Snapshot should include users with 1 failure, got %d", m.Failures)
+	}
+}
+
+func TestRegistryOverrides(t *testing.T) {
+	var opened []string
+	settings := circuit.DefaultSettings()
+	settings.OnStateChange = func(name string, change circuit.StateChange) {
+		if change.To == circuit.StateForcedOpen {
+			opened = append(opened, name)
+		}
+	}
+
+	registry := circuit.NewRegistry(settings)
+
+	registry.ForceOpen("search")
+	if registry.Get("search").Allow() {
+		t.Errorf("Forced open breaker should reject calls")
+	}
+	if len(opened) != 1 || opened[0] != "search" {
+		t.Errorf("Expected a state change notification for search, got %v", opened)
+	}
+
+	registry.ForceClosed("search")
+	for range 10 {
+		registry.Get("search").Failure()
+	}
+	if !registry.Get("search").Allow() {
+		t.Errorf("Forced closed breaker should allow calls despite failures")
+	}
+
+	registry.Reset("search")
+	if registry.Get("search").State() != circuit.StateClosed {
+		t.Errorf("Resetting should leave the breaker CLOSED, got %v", registry.Get("search").State())
+	}
+}
+
+func TestRegistryEvict(t *testing.T) {
+	registry := circuit.NewRegistry(circuit.Settings{FailureThreshold: 1, ResetTimeout: time.Minute})
+
+	registry.Get("idle")
+	registry.Get("broken").Failure()
+	registry.ForceClosed("pinned")
+
+	time.Sleep(time.Millisecond * 20)
+	registry.Get("busy").Allow()
+
+	if evicted := registry.Evict(time.Millisecond * 10); evicted != 1 {
+		t.Errorf("Only the idle closed breaker should be evicted, evicted %d", evicted)
+	}
+
+	names := registry.Names()
+	if len(names) != 3 {
+		t.Errorf("Expected broken, busy and pinned to remain, got %v", names)
+	}
+}
+
+func TestRegistryConfigureKeepsLiveBreaker(t *testing.T) {
+	var changes []string
+	registry := circuit.NewRegistry(circuit.DefaultSettings())
+
+	breaker := registry.Get("search")
+	registry.ForceOpen("search")
+
+	registry.Configure("search", circuit.Settings{
+		FailureThreshold: 1,
+		ResetTimeout:     time.Minute,
+		OnStateChange: func(name string, change circuit.StateChange) {
+			changes = append(changes, name+":"+change.To.String())
+		},
+	})
+
+	if registry.Get("search") != breaker {
+		t.Fatal("Configure should update the live breaker rather than replace it")
+	}
+	if state := breaker.State(); state != circuit.StateForcedOpen {
+		t.Fatalf("Configure should keep the operator override, got %v", state)
+	}
+
+	registry.Reset("search")
+	breaker.Failure()
+
+	if state := breaker.State(); state != circuit.StateOpen {
+		t.Errorf("The new threshold of 1 should apply, got %v", state)
+	}
+	if len(changes) != 2 || changes[1] != "search:OPEN" {
+		t.Errorf("The new listener should see the transitions, got %v", changes)
+	}
+}
@@ -2,8 +2,50 @@ package config
 
 import (
 	"time"
+
+	"github.com/estavadormir/adaptlimit/metrics"
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+// CPUSignal selects which CPU reading from the metrics collector drives adjustment
+type CPUSignal int
+
+const (
+	//cgroup usage against the quota, or the load average per CPU
+	CPUSignalLoad CPUSignal = iota
+
+	//CPU time used by this process
+	CPUSignalProcess
+
+	//non-idle CPU time across the host
+	CPUSignalSystem
+)
+
+// PredictionMode selects whether limits are scaled ahead of forecast demand
+type PredictionMode int
+
+const (
+	PredictionOff PredictionMode = iota
+
+	//each key forecasts its own demand
+	PredictionPerKey
+
+	//one forecast of total demand scales every key by its current share
+	PredictionGlobal
 )
 
+// Signal is a custom SignalSource and how strongly it steers adjustment
+type Signal struct {
+	Source metrics.SignalSource
+
+	//readings below LowThreshold raise limits, readings above HighThreshold lower them (0.0-1.0)
+	LowThreshold  float64
+	HighThreshold float64
+
+	//scales the step: 1 lowers by 20% or raises by 10% like CPU load, 0 ignores the signal
+	Weight float64
+}
+
 type Config struct {
 	//the init rate limit per interval
 	InitialLimit int
@@ -37,6 +79,56 @@ type Config struct {
 
 	//the target response time for requests
 	TargetResponseTime time.Duration
+
+	//the response time percentile (0.0-1.0) held to TargetResponseTime, 0 uses the mean
+	LatencyPercentile float64
+
+	//which CPU reading is compared against the load thresholds
+	CPUSignal CPUSignal
+
+	//how recent CPU and memory samples are summarised before comparing them
+	LoadView metrics.View
+
+	//the share of time (0.0-1.0) tasks may stall on CPU, memory or IO before limits are reduced, 0 disables
+	HighPressureThreshold float64
+
+	//the share of CPU time (0.0-1.0) the Go GC may use before limits are reduced, 0 disables
+	HighGCThreshold float64
+
+	//the p99 scheduler latency above which limits are reduced, 0 disables
+	MaxSchedulerLatency time.Duration
+
+	//additional signals fed into the adjustment
+	Signals []Signal
+
+	//the metrics source, nil starts a metrics.Collector polling every MetricsInterval
+	Collector metrics.Provider
+
+	//whether limits follow forecast demand
+	Prediction PredictionMode
+
+	//how many adjust intervals ahead demand is forecast
+	PredictionHorizon int
+
+	//the multiple of forecast demand the limit is scaled to
+	PredictionHeadroom float64
+
+	//options for the forecasters, e.g. predict.WithSeasonLength
+	PredictionOptions []predict.Option
+
+	//forecasts are not used to pre-scale while the MAPE of the recent ones, against the demand
+	//that arrived at the horizon, is above this; 0 trusts every forecast
+	PredictionMaxError float64
+
+	//the multiple of its rate a key is cut to when its demand spikes, 0 never tightens
+	AnomalyFactor float64
+
+	//how many adjust intervals a tightened key is held at the reduced rate
+	AnomalyCooldown int
+
+	//called with every demand anomaly detected per key; the detector is set with
+	//predict.WithAnomalyDetector in PredictionOptions
+	OnAnomaly func(key string, anomaly predict.Anomaly)
 }
 
 func DefaultConfig() *Config {
@@ -52,6 +144,9 @@ func DefaultConfig() *Config {
 		HighErrorThreshold: 0.05,
 		LowErrorThreshold:  0.01,
 		TargetResponseTime: time.Millisecond * 200,
+		PredictionHorizon:  1,
+		PredictionHeadroom: 1.2,
+		AnomalyCooldown:    3,
 	}
 }
 
@@ -101,3 +196,71 @@ func (c *Config) WithTargetResponseTime(duration time.Duration) *Config {
 	c.TargetResponseTime = duration
 	return c
 }
+
+func (c *Config) WithLatencyPercentile(percentile float64) *Config {
+	c.LatencyPercentile = percentile
+	return c
+}
+
+func (c *Config) WithCPUSignal(signal CPUSignal) *Config {
+	c.CPUSignal = signal
+	return c
+}
+
+func (c *Config) WithLoadView(view metrics.View) *Config {
+	c.LoadView = view
+	return c
+}
+
+func (c *Config) WithPressureThreshold(high float64) *Config {
+	c.HighPressureThreshold = high
+	return c
+}
+
+func (c *Config) WithGCThreshold(high float64) *Config {
+	c.HighGCThreshold = high
+	return c
+}
+
+func (c *Config) WithMaxSchedulerLatency(latency time.Duration) *Config {
+	c.MaxSchedulerLatency = latency
+	return c
+}
+
+func (c *Config) WithCollector(collector metrics.Provider) *Config {
+	c.Collector = collector
+	return c
+}
+
+// WithPrediction forecasts demand horizon adjust intervals ahead and scales limits to it plus headroom
+func (c *Config) WithPrediction(mode PredictionMode, horizon int, headroom float64, options ...predict.Option) *Config {
+	c.Prediction = mode
+	c.PredictionHorizon = horizon
+	c.PredictionHeadroom = headroom
+	c.PredictionOptions = options
+	return c
+}
+
+func (c *Config) WithPredictionMaxError(mape float64) *Config {
+	c.PredictionMaxError = mape
+	return c
+}
+
+// WithAnomalyDetection tracks per-key demand and cuts a key's rate by factor for cooldown
+// adjust intervals when it spikes; onAnomaly, if set, sees both spikes and drops
+func (c *Config) WithAnomalyDetection(factor float64, cooldown int, onAnomaly func(key string, anomaly predict.Anomaly)) *Config {
+	c.AnomalyFactor = factor
+	c.AnomalyCooldown = cooldown
+	c.OnAnomaly = onAnomaly
+	return c
+}
+
+func (c *Config) WithSignal(source metrics.SignalSource, low, high, weight float64) *Config {
+	c.Signals = append(c.Signals, Signal{
+		Source:        source,
+		LowThreshold:  low,
+		HighThreshold: high,
+		Weight:        weight,
+	})
+	return c
+}
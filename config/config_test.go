@@ -50,3 +50,15 @@ func TestConfigChaining(t *testing.T) {
 		t.Errorf("Expected MaxLimit to be 200, got %d", cfg.MaxLimit)
 	}
 }
+
+func TestConfigCPUSignal(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if cfg.CPUSignal != config.CPUSignalLoad {
+		t.Errorf("Expected CPUSignal to default to load, got %v", cfg.CPUSignal)
+	}
+
+	cfg = cfg.WithCPUSignal(config.CPUSignalProcess)
+	if cfg.CPUSignal != config.CPUSignalProcess {
+		t.Errorf("Expected CPUSignal to be process, got %v", cfg.CPUSignal)
+	}
+}
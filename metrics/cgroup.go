@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// memory limits at or above this are how cgroup v1 spells "unlimited"
+const unlimitedMemory = int64(1) << 60
+
+// cgroup reads the CPU quota and memory limit of the container the process runs in
+type cgroup struct {
+	root    string
+	version int
+
+	lastUsage  time.Duration
+	lastSample time.Time
+}
+
+func detectCgroup(root string) *cgroup {
+	if fileExists(filepath.Join(root, "cgroup.controllers")) {
+		return &cgroup{root: root, version: 2}
+	}
+
+	if fileExists(filepath.Join(root, "cpuacct", "cpuacct.usage")) ||
+		fileExists(filepath.Join(root, "memory", "memory.usage_in_bytes")) {
+		return &cgroup{root: root, version: 1}
+	}
+
+	return nil
+}
+
+// cpuLimit returns the CPU quota in cores, or 0 when the cgroup is unlimited
+func (g *cgroup) cpuLimit() float64 {
+	var quota, period int64
+
+	if g.version == 2 {
+		fields := strings.Fields(g.read("cpu.max"))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0
+		}
+		quota, _ = strconv.ParseInt(fields[0], 10, 64)
+		period, _ = strconv.ParseInt(fields[1], 10, 64)
+	} else {
+		quota, _ = strconv.ParseInt(g.read("cpu", "cpu.cfs_quota_us"), 10, 64)
+		period, _ = strconv.ParseInt(g.read("cpu", "cpu.cfs_period_us"), 10, 64)
+	}
+
+	if quota <= 0 || period <= 0 {
+		return 0
+	}
+	return float64(quota) / float64(period)
+}
+
+func (g *cgroup) cpuUsage() (time.Duration, bool) {
+	if g.version == 2 {
+		usec, ok := statValue(g.read("cpu.stat"), "usage_usec")
+		return time.Duration(usec) * time.Microsecond, ok
+	}
+
+	nsec, err := strconv.ParseInt(g.read("cpuacct", "cpuacct.usage"), 10, 64)
+	return time.Duration(nsec), err == nil
+}
+
+// cpuLoad returns CPU time used since the previous call relative to the quota,
+// or to cpus when there is none. The first call only records a baseline.
+func (g *cgroup) cpuLoad(now time.Time, cpus int) (float64, bool) {
+	usage, ok := g.cpuUsage()
+	if !ok {
+		return 0, false
+	}
+
+	lastUsage, lastSample := g.lastUsage, g.lastSample
+	g.lastUsage, g.lastSample = usage, now
+
+	elapsed := now.Sub(lastSample)
+	if lastSample.IsZero() || elapsed <= 0 || usage < lastUsage {
+		return 0, false
+	}
+
+	limit := g.cpuLimit()
+	if limit == 0 {
+		limit = float64(cpus)
+	}
+
+	load := float64(usage-lastUsage) / (float64(elapsed) * limit)
+	return min(load, 1.0), true
+}
+
+// memoryLoad returns the working set (usage minus inactive page cache) over the limit
+func (g *cgroup) memoryLoad() (float64, bool) {
+	var usage, limit, inactive int64
+	var err error
+
+	if g.version == 2 {
+		usage, err = strconv.ParseInt(g.read("memory.current"), 10, 64)
+		limit, _ = strconv.ParseInt(g.read("memory.max"), 10, 64)
+		inactive, _ = statValue(g.read("memory.stat"), "inactive_file")
+	} else {
+		usage, err = strconv.ParseInt(g.read("memory", "memory.usage_in_bytes"), 10, 64)
+		limit, _ = strconv.ParseInt(g.read("memory", "memory.limit_in_bytes"), 10, 64)
+		inactive, _ = statValue(g.read("memory", "memory.stat"), "total_inactive_file")
+	}
+
+	if err != nil || limit <= 0 || limit >= unlimitedMemory {
+		return 0, false
+	}
+
+	if inactive < usage {
+		usage -= inactive
+	}
+	return min(float64(usage)/float64(limit), 1.0), true
+}
+
+func (g *cgroup) read(path ...string) string {
+	data, err := os.ReadFile(filepath.Join(append([]string{g.root}, path...)...))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// statValue finds key in a flat "key value" file such as cpu.stat or memory.stat
+func statValue(content, key string) (int64, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			value, err := strconv.ParseInt(fields[1], 10, 64)
+			return value, err == nil
+		}
+	}
+	return 0, false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
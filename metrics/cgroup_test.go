@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFixture(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCgroupV2(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"cgroup.controllers": "cpu memory io\n",
+		"cpu.max":            "200000 100000\n",
+		"cpu.stat":           "usage_usec 1000000\nuser_usec 800000\nsystem_usec 200000\n",
+		"memory.current":     "600\n",
+		"memory.max":         "1000\n",
+		"memory.stat":        "anon 300\ninactive_file 100\n",
+	})
+
+	g := detectCgroup(root)
+	if g == nil || g.version != 2 {
+		t.Fatalf("Expected cgroup v2 to be detected, got %+v", g)
+	}
+
+	if limit := g.cpuLimit(); limit != 2 {
+		t.Errorf("Expected a 2 CPU quota, got %f", limit)
+	}
+
+	start := time.Now()
+	if _, ok := g.cpuLoad(start, 64); ok {
+		t.Errorf("First CPU sample should only record a baseline")
+	}
+
+	//one CPU-second used over one wall-second against a 2 CPU quota
+	writeFixture(t, root, map[string]string{"cpu.stat": "usage_usec 2000000\n"})
+	load, ok := g.cpuLoad(start.Add(time.Second), 64)
+	if !ok || load != 0.5 {
+		t.Errorf("Expected CPU load 0.5, got %f (ok=%v)", load, ok)
+	}
+
+	load, ok = g.memoryLoad()
+	if !ok || load != 0.5 {
+		t.Errorf("Expected memory load 0.5 excluding inactive file cache, got %f (ok=%v)", load, ok)
+	}
+
+	writeFixture(t, root, map[string]string{"cpu.max": "max 100000\n", "memory.max": "max\n"})
+	if limit := g.cpuLimit(); limit != 0 {
+		t.Errorf("Unlimited cpu.max should report 0, got %f", limit)
+	}
+	if _, ok := g.memoryLoad(); ok {
+		t.Errorf("Unlimited memory.max should fall back to host memory")
+	}
+}
+
+func TestCgroupV1(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"cpu/cpu.cfs_quota_us":         "50000\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+		"cpuacct/cpuacct.usage":        "0\n",
+		"memory/memory.usage_in_bytes": "800\n",
+		"memory/memory.limit_in_bytes": "1000\n",
+		"memory/memory.stat":           "cache 400\ntotal_inactive_file 0\n",
+	})
+
+	g := detectCgroup(root)
+	if g == nil || g.version != 1 {
+		t.Fatalf("Expected cgroup v1 to be detected, got %+v", g)
+	}
+
+	if limit := g.cpuLimit(); limit != 0.5 {
+		t.Errorf("Expected a 0.5 CPU quota, got %f", limit)
+	}
+
+	start := time.Now()
+	g.cpuLoad(start, 64)
+	writeFixture(t, root, map[string]string{"cpuacct/cpuacct.usage": "250000000\n"})
+	load, ok := g.cpuLoad(start.Add(time.Second), 64)
+	if !ok || load != 0.5 {
+		t.Errorf("Expected CPU load 0.5, got %f (ok=%v)", load, ok)
+	}
+
+	load, ok = g.memoryLoad()
+	if !ok || load != 0.8 {
+		t.Errorf("Expected memory load 0.8, got %f (ok=%v)", load, ok)
+	}
+
+	writeFixture(t, root, map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712\n"})
+	if _, ok := g.memoryLoad(); ok {
+		t.Errorf("Unlimited memory.limit_in_bytes should fall back to host memory")
+	}
+}
+
+func TestCgroupMissing(t *testing.T) {
+	if g := detectCgroup(t.TempDir()); g != nil {
+		t.Errorf("Expected no cgroup in an empty root, got %+v", g)
+	}
+}
@@ -3,24 +3,107 @@ package metrics
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 	"time"
 )
 
+// Provider is the set of readings the limiter adapts to; Collector is the real implementation
+type Provider interface {
+	CPULoad() float64
+	MemoryLoad() float64
+	ProcessCPU() float64
+	SystemCPU() float64
+	CPUPressure() Pressure
+	MemoryPressure() Pressure
+	IOPressure() Pressure
+	Runtime() RuntimeStats
+	View(reading Reading, view View) float64
+	Stop()
+}
+
 type Collector struct {
 	cpuLoad    float64
 	memoryLoad float64
+	processCPU float64
+	systemCPU  float64
+	cpuPSI     Pressure
+	memoryPSI  Pressure
+	ioPSI      Pressure
+	runtime    RuntimeStats
+	sampler    *runtimeSampler
 	interval   time.Duration
-	mu         sync.RWMutex
-	stopCh     chan struct{}
+	procRoot   string
+	cgroupRoot string
+	cgroup     *cgroup
+	lastCPU    cpuSample
+	windowSize int
+	ewmaAlpha  float64
+	windows    map[Reading]*window
+
+	networkEnabled bool
+	networkSampled bool
+	network        NetworkStats
+	lastTCP        tcpCounters
+	mu             sync.RWMutex
+	stopCh         chan struct{}
+	doneCh         chan struct{}
+
+	//guarded by sharedMu
+	refs   int
+	shared bool
+}
+
+var (
+	sharedMu   sync.Mutex
+	collectors = make(map[time.Duration]*Collector)
+)
+
+// Shared returns the process-wide collector for interval, starting it on first use.
+// Every call must be paired with a Stop; the collector stops when the last user does.
+func Shared(interval time.Duration) *Collector {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	if c, ok := collectors[interval]; ok {
+		c.refs++
+		return c
+	}
+
+	c := NewCollector(interval)
+	c.shared = true
+	collectors[interval] = c
+	return c
 }
 
-func NewCollector(interval time.Duration) *Collector {
+func NewCollector(interval time.Duration, options ...Option) *Collector {
 	c := &Collector{
-		interval: interval,
-		stopCh:   make(chan struct{}),
+		interval:   interval,
+		procRoot:   "/proc",
+		cgroupRoot: "/sys/fs/cgroup",
+		sampler:    newRuntimeSampler(),
+		windowSize: 12,
+		ewmaAlpha:  0.3,
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+		refs:       1,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	if runtime.GOOS == "linux" {
+		c.cgroup = detectCgroup(c.cgroupRoot)
+	}
+
+	c.windows = map[Reading]*window{
+		ReadingCPU:        newWindow(c.windowSize, c.ewmaAlpha),
+		ReadingMemory:     newWindow(c.windowSize, c.ewmaAlpha),
+		ReadingProcessCPU: newWindow(c.windowSize, c.ewmaAlpha),
+		ReadingSystemCPU:  newWindow(c.windowSize, c.ewmaAlpha),
 	}
 
 	go c.collect()
@@ -28,6 +111,47 @@ func NewCollector(interval time.Duration) *Collector {
 	return c
 }
 
+type Option func(*Collector)
+
+// WithProcRoot reads host metrics from a procfs mounted somewhere other than /proc
+func WithProcRoot(path string) Option {
+	return func(c *Collector) {
+		c.procRoot = path
+	}
+}
+
+// WithWindow keeps the last size samples of each reading for the windowed views
+func WithWindow(size int) Option {
+	return func(c *Collector) {
+		if size > 0 {
+			c.windowSize = size
+		}
+	}
+}
+
+// WithEWMAAlpha sets the weight (0.0-1.0) of the newest sample in ViewEWMA
+func WithEWMAAlpha(alpha float64) Option {
+	return func(c *Collector) {
+		if alpha > 0 && alpha <= 1 {
+			c.ewmaAlpha = alpha
+		}
+	}
+}
+
+// WithNetworkStats also reads file descriptor, socket and TCP accept queue usage
+func WithNetworkStats() Option {
+	return func(c *Collector) {
+		c.networkEnabled = true
+	}
+}
+
+// WithCgroupRoot reads container limits from a cgroupfs other than /sys/fs/cgroup
+func WithCgroupRoot(path string) Option {
+	return func(c *Collector) {
+		c.cgroupRoot = path
+	}
+}
+
 func (c *Collector) CPULoad() float64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -40,11 +164,110 @@ func (c *Collector) MemoryLoad() float64 {
 	return c.memoryLoad
 }
 
+// ProcessCPU is the share of the available CPUs (or cgroup quota) this process used since the last tick
+func (c *Collector) ProcessCPU() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.processCPU
+}
+
+// SystemCPU is the share of host CPU time spent non-idle since the last tick
+func (c *Collector) SystemCPU() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.systemCPU
+}
+
+func (c *Collector) CPUPressure() Pressure {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cpuPSI
+}
+
+func (c *Collector) MemoryPressure() Pressure {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.memoryPSI
+}
+
+func (c *Collector) IOPressure() Pressure {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ioPSI
+}
+
+// View summarises the recent samples of reading; ViewLatest matches the plain accessors
+func (c *Collector) View(reading Reading, view View) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	w, ok := c.windows[reading]
+	if !ok {
+		return 0
+	}
+	return w.view(view)
+}
+
+// Network is only populated when the collector was created WithNetworkStats
+func (c *Collector) Network() NetworkStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.network
+}
+
+// FDSignal reports open file descriptors over RLIMIT_NOFILE, for use with config.WithSignal
+func (c *Collector) FDSignal() SignalSource {
+	return NewSignal("open-fds", func() float64 {
+		return c.Network().FDLoad()
+	}, func() bool {
+		return c.Network().FDLimit > 0
+	})
+}
+
+// ListenQueueSignal reports the share of incoming TCP connections dropped by full accept queues
+func (c *Collector) ListenQueueSignal() SignalSource {
+	return NewSignal("listen-drops", func() float64 {
+		return c.Network().ListenDropRatio()
+	}, func() bool {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.networkSampled
+	})
+}
+
+func (c *Collector) Runtime() RuntimeStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.runtime
+}
+
+// Stop releases the collector and, once no users remain, waits for it to stop polling.
+// Calling it more times than the collector was acquired is a no-op.
 func (c *Collector) Stop() {
+	sharedMu.Lock()
+	if c.refs == 0 {
+		sharedMu.Unlock()
+		return
+	}
+
+	c.refs--
+	if c.refs > 0 {
+		sharedMu.Unlock()
+		return
+	}
+
+	if c.shared && collectors[c.interval] == c {
+		delete(collectors, c.interval)
+	}
+	sharedMu.Unlock()
+
 	close(c.stopCh)
+	<-c.doneCh
 }
 
 func (c *Collector) collect() {
+	defer close(c.doneCh)
+
 	ticker := time.NewTicker(c.interval)
 	defer ticker.Stop()
 
@@ -64,36 +287,135 @@ func (c *Collector) updateMetrics() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
+	c.runtime = c.sampler.read()
 
-	numGoroutines := runtime.NumGoroutine()
 	maxGoroutines := 1000
-	c.cpuLoad = float64(numGoroutines) / float64(maxGoroutines)
+	c.cpuLoad = float64(c.runtime.Goroutines) / float64(maxGoroutines)
 	if c.cpuLoad > 1.0 {
 		c.cpuLoad = 1.0
 	}
 
-	c.memoryLoad = float64(m.Alloc) / float64(m.Sys)
+	c.memoryLoad = c.runtime.HeapInUseRatio
+
+	//without procfs the utilization views fall back to the same estimate
+	c.processCPU = c.cpuLoad
+	c.systemCPU = c.cpuLoad
 
 	if runtime.GOOS == "linux" {
 		c.updateLinuxMetrics()
+
+		if c.networkEnabled {
+			c.updateNetwork()
+		}
 	}
+
+	c.windows[ReadingCPU].add(c.cpuLoad)
+	c.windows[ReadingMemory].add(c.memoryLoad)
+	c.windows[ReadingProcessCPU].add(c.processCPU)
+	c.windows[ReadingSystemCPU].add(c.systemCPU)
 }
 
 func (c *Collector) updateLinuxMetrics() {
-	loadBytes, err := os.ReadFile("/proc/loadavg")
+	//prefer the container's own quota and limit, falling back to host-wide figures
+	cpuDone, memDone := false, false
+	cpuCount := float64(runtime.NumCPU())
+
+	if c.cgroup != nil {
+		if limit := c.cgroup.cpuLimit(); limit > 0 {
+			cpuCount = limit
+		}
+
+		if load, ok := c.cgroup.cpuLoad(time.Now(), runtime.NumCPU()); ok {
+			c.cpuLoad = load
+			cpuDone = true
+		}
+
+		if load, ok := c.cgroup.memoryLoad(); ok {
+			c.memoryLoad = load
+			memDone = true
+		}
+	}
+
+	if !cpuDone {
+		c.updateLoadAverage(cpuCount)
+	}
+
+	c.updateCPUUtilization(time.Now(), cpuCount)
+
+	if !memDone {
+		c.updateMemInfo()
+	}
+
+	c.updatePressure()
+}
+
+// updatePressure reads PSI from the cgroup v2 *.pressure files, falling back to host-wide
+// /proc/pressure when they are missing, e.g. with cgroup.pressure set to 0
+func (c *Collector) updatePressure() {
+	read := func(resource string) (Pressure, bool) {
+		if c.cgroup != nil && c.cgroup.version == 2 {
+			if p, ok := readPressure(filepath.Join(c.cgroup.root, resource+".pressure")); ok {
+				return p, true
+			}
+		}
+		return readPressure(filepath.Join(c.procRoot, "pressure", resource))
+	}
+
+	if p, ok := read("cpu"); ok {
+		c.cpuPSI = p
+	}
+	if p, ok := read("memory"); ok {
+		c.memoryPSI = p
+	}
+	if p, ok := read("io"); ok {
+		c.ioPSI = p
+	}
+}
+
+type cpuSample struct {
+	at      time.Time
+	process uint64
+	system  cpuTimes
+}
+
+func (c *Collector) updateCPUUtilization(now time.Time, cpuCount float64) {
+	process, processOK := readProcessCPU(c.procRoot)
+	system, systemOK := readSystemCPU(c.procRoot)
+
+	last := c.lastCPU
+	c.lastCPU = cpuSample{at: now, process: process, system: system}
+
+	if last.at.IsZero() {
+		return
+	}
+
+	elapsed := now.Sub(last.at).Seconds()
+	if processOK && elapsed > 0 && process >= last.process {
+		used := float64(process-last.process) / clockTicks
+		c.processCPU = min(used/(elapsed*cpuCount), 1.0)
+	}
+
+	if systemOK && system.total > last.system.total && system.idle >= last.system.idle {
+		idle := float64(system.idle - last.system.idle)
+		total := float64(system.total - last.system.total)
+		c.systemCPU = min(1.0-idle/total, 1.0)
+	}
+}
+
+func (c *Collector) updateLoadAverage(cpuCount float64) {
+	loadBytes, err := os.ReadFile(filepath.Join(c.procRoot, "loadavg"))
 	if err == nil && len(loadBytes) > 0 {
 		var load float64
 		_, err = fmt.Sscanf(string(loadBytes), "%f", &load)
 		if err == nil {
-			cpuCount := runtime.NumCPU()
-			normalizedLoad := load / float64(cpuCount)
+			normalizedLoad := load / cpuCount
 			c.cpuLoad = min(normalizedLoad, 1.0)
 		}
 	}
+}
 
-	memBytes, err := os.ReadFile("/proc/meminfo")
+func (c *Collector) updateMemInfo() {
+	memBytes, err := os.ReadFile(filepath.Join(c.procRoot, "meminfo"))
 	if err == nil {
 		var total, free, available int64
 		lines := strings.Split(string(memBytes), "\n")
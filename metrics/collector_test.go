@@ -1,6 +1,9 @@
 package metrics_test
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -24,3 +27,113 @@ func TestMetricsCollector(t *testing.T) {
 		t.Errorf("MemoryLoad should be between 0 and 1, got %f", memLoad)
 	}
 }
+
+func TestMetricsCollectorCgroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("cgroup metrics are only read on linux")
+	}
+
+	root := t.TempDir()
+	files := map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"cpu.max":            "max 100000\n",
+		"cpu.stat":           "usage_usec 0\n",
+		"memory.current":     "250\n",
+		"memory.max":         "1000\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collector := metrics.NewCollector(time.Millisecond*50,
+		metrics.WithCgroupRoot(root),
+		metrics.WithProcRoot(t.TempDir()))
+	defer collector.Stop()
+
+	time.Sleep(time.Millisecond * 100)
+
+	if memLoad := collector.MemoryLoad(); memLoad != 0.25 {
+		t.Errorf("MemoryLoad should come from the cgroup limit, expected 0.25, got %f", memLoad)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	depth := 0.4
+	signal := metrics.NewSignal("queue-depth", func() float64 { return depth }, nil)
+
+	if signal.Name() != "queue-depth" || signal.Value() != 0.4 || !signal.Healthy() {
+		t.Errorf("Unexpected signal reading: %s=%f healthy=%v", signal.Name(), signal.Value(), signal.Healthy())
+	}
+
+	healthy := false
+	signal = metrics.NewSignal("db-pool", func() float64 { return 1 }, func() bool { return healthy })
+	if signal.Healthy() {
+		t.Errorf("Signal should report unhealthy")
+	}
+}
+
+func TestSharedCollector(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	first := metrics.Shared(time.Millisecond * 50)
+	second := metrics.Shared(time.Millisecond * 50)
+	if first != second {
+		t.Fatalf("Shared should return the same collector for the same interval")
+	}
+
+	first.Stop()
+	if runtime.NumGoroutine() <= baseline {
+		t.Errorf("Collector should keep running while it still has users")
+	}
+
+	second.Stop()
+	second.Stop()
+	if n := runtime.NumGoroutine(); n > baseline {
+		t.Errorf("Collector goroutine leaked after the last Stop: %d goroutines, expected %d", n, baseline)
+	}
+
+	third := metrics.Shared(time.Millisecond * 50)
+	defer third.Stop()
+	if third == first {
+		t.Errorf("A stopped shared collector should not be handed out again")
+	}
+}
+
+func TestMetricsCollectorViews(t *testing.T) {
+	collector := metrics.NewCollector(time.Millisecond*20, metrics.WithWindow(50), metrics.WithEWMAAlpha(0.5))
+	defer collector.Stop()
+
+	time.Sleep(time.Millisecond * 100)
+
+	if latest := collector.View(metrics.ReadingMemory, metrics.ViewLatest); latest != collector.MemoryLoad() {
+		t.Errorf("Latest view should match MemoryLoad, got %f and %f", latest, collector.MemoryLoad())
+	}
+
+	lo := collector.View(metrics.ReadingCPU, metrics.ViewMin)
+	hi := collector.View(metrics.ReadingCPU, metrics.ViewMax)
+	ewma := collector.View(metrics.ReadingCPU, metrics.ViewEWMA)
+	if lo > hi || ewma < lo-1e-9 || ewma > hi+1e-9 {
+		t.Errorf("Expected min <= ewma <= max, got %f, %f, %f", lo, ewma, hi)
+	}
+}
+
+func TestMetricsCollectorNetworkSignals(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("network stats are only read on linux")
+	}
+
+	collector := metrics.NewCollector(time.Millisecond*20, metrics.WithNetworkStats())
+	defer collector.Stop()
+
+	time.Sleep(time.Millisecond * 60)
+
+	fds := collector.FDSignal()
+	if !fds.Healthy() {
+		t.Skip("RLIMIT_NOFILE is not available")
+	}
+	if v := fds.Value(); v <= 0 || v > 1 {
+		t.Errorf("FD signal should be between 0 and 1 with open files, got %f", v)
+	}
+}
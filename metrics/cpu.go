@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// USER_HZ, the unit of the tick counters in /proc; fixed at 100 on every mainstream architecture
+const clockTicks = 100
+
+type cpuTimes struct {
+	idle  uint64
+	total uint64
+}
+
+// readSystemCPU parses the aggregate "cpu" line of /proc/stat
+func readSystemCPU(procRoot string) (cpuTimes, bool) {
+	data, err := os.ReadFile(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return cpuTimes{}, false
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		var times cpuTimes
+		for i, field := range fields[1:] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return cpuTimes{}, false
+			}
+
+			//guest time is already included in user and nice
+			if i >= 8 {
+				break
+			}
+
+			times.total += value
+			if i == 3 || i == 4 { // idle and iowait
+				times.idle += value
+			}
+		}
+		return times, true
+	}
+
+	return cpuTimes{}, false
+}
+
+// readProcessCPU returns utime+stime of this process in clock ticks from /proc/self/stat
+func readProcessCPU(procRoot string) (uint64, bool) {
+	data, err := os.ReadFile(filepath.Join(procRoot, "self", "stat"))
+	if err != nil {
+		return 0, false
+	}
+
+	//the command name may contain spaces, so fields are counted from its closing paren
+	content := string(data)
+	end := strings.LastIndexByte(content, ')')
+	if end < 0 {
+		return 0, false
+	}
+
+	fields := strings.Fields(content[end+1:])
+	if len(fields) < 13 {
+		return 0, false
+	}
+
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return utime + stime, true
+}
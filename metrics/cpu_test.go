@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCPUUtilization(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"stat":      "cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 50 0 50 350 50 0 0 0 0 0\n",
+		"self/stat": "42 (my (odd) app) S 1 42 42 0 -1 4194560 100 0 0 0 10 10 0 0 20 0 8 0 100 1000 100\n",
+	})
+
+	c := &Collector{procRoot: root}
+	start := time.Now()
+	c.updateCPUUtilization(start, 2)
+
+	//400 more ticks on the host, 100 of them idle; the process used 1 CPU-second of 2
+	writeFixture(t, root, map[string]string{
+		"stat":      "cpu  250 0 250 750 150 0 0 0 0 0\n",
+		"self/stat": "42 (my (odd) app) S 1 42 42 0 -1 4194560 100 0 0 0 80 40 0 0 20 0 8 0 100 1000 100\n",
+	})
+	c.updateCPUUtilization(start.Add(time.Second), 2)
+
+	if c.systemCPU != 0.75 {
+		t.Errorf("Expected system CPU 0.75, got %f", c.systemCPU)
+	}
+	if c.processCPU != 0.5 {
+		t.Errorf("Expected process CPU 0.5, got %f", c.processCPU)
+	}
+}
+
+func TestCPUUtilizationMissingProc(t *testing.T) {
+	c := &Collector{procRoot: t.TempDir(), processCPU: 0.3, systemCPU: 0.4}
+	start := time.Now()
+	c.updateCPUUtilization(start, 1)
+	c.updateCPUUtilization(start.Add(time.Second), 1)
+
+	if c.processCPU != 0.3 || c.systemCPU != 0.4 {
+		t.Errorf("Missing procfs should keep the fallback values, got %f and %f", c.processCPU, c.systemCPU)
+	}
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// Sample is one reading of every value a Fake reports
+type Sample struct {
+	CPU        float64
+	Memory     float64
+	ProcessCPU float64
+	SystemCPU  float64
+
+	CPUPressure    Pressure
+	MemoryPressure Pressure
+	IOPressure     Pressure
+
+	Runtime RuntimeStats
+}
+
+// Fake is a scriptable Provider for tests; it reports a fixed sample or plays back a series
+type Fake struct {
+	current Sample
+	series  []Sample
+	step    time.Duration
+	start   time.Time
+	stopped bool
+	mu      sync.RWMutex
+}
+
+func NewFake(initial Sample) *Fake {
+	return &Fake{current: initial}
+}
+
+// Set reports s from now on, ending any playback
+func (f *Fake) Set(s Sample) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current = s
+	f.series = nil
+}
+
+func (f *Fake) SetCPULoad(load float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current = f.sample(time.Now())
+	f.current.CPU = load
+	f.series = nil
+}
+
+func (f *Fake) SetMemoryLoad(load float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current = f.sample(time.Now())
+	f.current.Memory = load
+	f.series = nil
+}
+
+// Play reports series[i] from i*step after the call; the last sample is held once the series ends
+func (f *Fake) Play(step time.Duration, series ...Sample) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(series) == 0 || step <= 0 {
+		return
+	}
+
+	f.series = series
+	f.step = step
+	f.start = time.Now()
+}
+
+func (f *Fake) sample(now time.Time) Sample {
+	if len(f.series) == 0 {
+		return f.current
+	}
+
+	i := int(now.Sub(f.start) / f.step)
+	if i >= len(f.series) {
+		i = len(f.series) - 1
+	}
+	return f.series[i]
+}
+
+func (f *Fake) read() Sample {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.sample(time.Now())
+}
+
+func (f *Fake) CPULoad() float64 {
+	return f.read().CPU
+}
+
+func (f *Fake) MemoryLoad() float64 {
+	return f.read().Memory
+}
+
+func (f *Fake) ProcessCPU() float64 {
+	return f.read().ProcessCPU
+}
+
+func (f *Fake) SystemCPU() float64 {
+	return f.read().SystemCPU
+}
+
+func (f *Fake) CPUPressure() Pressure {
+	return f.read().CPUPressure
+}
+
+func (f *Fake) MemoryPressure() Pressure {
+	return f.read().MemoryPressure
+}
+
+func (f *Fake) IOPressure() Pressure {
+	return f.read().IOPressure
+}
+
+// View ignores view and returns the current value of reading; script a series with Play instead
+func (f *Fake) View(reading Reading, view View) float64 {
+	s := f.read()
+
+	switch reading {
+	case ReadingCPU:
+		return s.CPU
+	case ReadingMemory:
+		return s.Memory
+	case ReadingProcessCPU:
+		return s.ProcessCPU
+	case ReadingSystemCPU:
+		return s.SystemCPU
+	default:
+		return 0
+	}
+}
+
+func (f *Fake) Runtime() RuntimeStats {
+	return f.read().Runtime
+}
+
+func (f *Fake) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+}
+
+func (f *Fake) Stopped() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.stopped
+}
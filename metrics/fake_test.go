@@ -0,0 +1,48 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/metrics"
+)
+
+func TestFakeSet(t *testing.T) {
+	fake := metrics.NewFake(metrics.Sample{CPU: 0.1, Memory: 0.2})
+
+	if fake.CPULoad() != 0.1 || fake.MemoryLoad() != 0.2 {
+		t.Errorf("Expected the initial sample, got cpu=%f mem=%f", fake.CPULoad(), fake.MemoryLoad())
+	}
+
+	fake.SetCPULoad(0.9)
+	if fake.CPULoad() != 0.9 || fake.MemoryLoad() != 0.2 {
+		t.Errorf("SetCPULoad should only change CPU, got cpu=%f mem=%f", fake.CPULoad(), fake.MemoryLoad())
+	}
+
+	fake.Stop()
+	if !fake.Stopped() {
+		t.Errorf("Fake should record that it was stopped")
+	}
+}
+
+func TestFakePlay(t *testing.T) {
+	fake := metrics.NewFake(metrics.Sample{})
+	fake.Play(time.Millisecond*50,
+		metrics.Sample{CPU: 0.1},
+		metrics.Sample{CPU: 0.5},
+		metrics.Sample{CPU: 0.9})
+
+	if fake.CPULoad() != 0.1 {
+		t.Errorf("Expected the first sample, got %f", fake.CPULoad())
+	}
+
+	time.Sleep(time.Millisecond * 75)
+	if fake.CPULoad() != 0.5 {
+		t.Errorf("Expected the second sample, got %f", fake.CPULoad())
+	}
+
+	time.Sleep(time.Millisecond * 100)
+	if fake.CPULoad() != 0.9 {
+		t.Errorf("Expected the last sample to be held, got %f", fake.CPULoad())
+	}
+}
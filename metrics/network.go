@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NetworkStats tracks resources services tend to run out of before CPU; counters cover the last interval
+type NetworkStats struct {
+	OpenFDs int
+	FDLimit uint64
+
+	//from /proc/net/sockstat
+	Sockets     int64
+	TCPInUse    int64
+	TCPOrphan   int64
+	TCPTimeWait int64
+	TCPAlloc    int64
+	TCPMemPages int64
+
+	//from /proc/net/netstat and /proc/net/snmp
+	ListenOverflows uint64
+	ListenDrops     uint64
+	PassiveOpens    uint64
+}
+
+// FDLoad is open file descriptors over RLIMIT_NOFILE
+func (s NetworkStats) FDLoad() float64 {
+	if s.FDLimit == 0 {
+		return 0
+	}
+	return min(float64(s.OpenFDs)/float64(s.FDLimit), 1.0)
+}
+
+// ListenDropRatio is the share of incoming connections dropped from a full accept queue
+func (s NetworkStats) ListenDropRatio() float64 {
+	attempts := s.PassiveOpens + s.ListenDrops
+	if attempts == 0 {
+		return 0
+	}
+	return float64(s.ListenDrops) / float64(attempts)
+}
+
+type tcpCounters struct {
+	overflows uint64
+	drops     uint64
+	passive   uint64
+}
+
+func (c *Collector) updateNetwork() {
+	stats := NetworkStats{}
+	ok := false
+
+	if entries, err := os.ReadDir(filepath.Join(c.procRoot, "self", "fd")); err == nil {
+		stats.OpenFDs = len(entries)
+		stats.FDLimit, _ = fdLimit()
+		ok = true
+	}
+
+	if sockstat := readSockstat(filepath.Join(c.procRoot, "net", "sockstat")); sockstat != nil {
+		stats.Sockets = sockstat["sockets"]["used"]
+		stats.TCPInUse = sockstat["TCP"]["inuse"]
+		stats.TCPOrphan = sockstat["TCP"]["orphan"]
+		stats.TCPTimeWait = sockstat["TCP"]["tw"]
+		stats.TCPAlloc = sockstat["TCP"]["alloc"]
+		stats.TCPMemPages = sockstat["TCP"]["mem"]
+		ok = true
+	}
+
+	netstat := readProcTable(filepath.Join(c.procRoot, "net", "netstat"))
+	snmp := readProcTable(filepath.Join(c.procRoot, "net", "snmp"))
+	counters := tcpCounters{
+		overflows: uint64(max(netstat["TcpExt"]["ListenOverflows"], 0)),
+		drops:     uint64(max(netstat["TcpExt"]["ListenDrops"], 0)),
+		passive:   uint64(max(snmp["Tcp"]["PassiveOpens"], 0)),
+	}
+
+	//counters are cumulative since boot, so only report growth since the previous tick
+	if c.networkSampled && counters.overflows >= c.lastTCP.overflows &&
+		counters.drops >= c.lastTCP.drops && counters.passive >= c.lastTCP.passive {
+		stats.ListenOverflows = counters.overflows - c.lastTCP.overflows
+		stats.ListenDrops = counters.drops - c.lastTCP.drops
+		stats.PassiveOpens = counters.passive - c.lastTCP.passive
+	}
+	c.lastTCP = counters
+	c.networkSampled = netstat != nil && snmp != nil
+
+	if ok {
+		c.network = stats
+	}
+}
+
+// readSockstat parses lines such as "TCP: inuse 5 orphan 0 tw 2 alloc 8 mem 1"
+func readSockstat(path string) map[string]map[string]int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	result := make(map[string]map[string]int64)
+	for _, line := range strings.Split(string(data), "\n") {
+		prefix, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		values := make(map[string]int64)
+		fields := strings.Fields(rest)
+		for i := 0; i+1 < len(fields); i += 2 {
+			values[fields[i]], _ = strconv.ParseInt(fields[i+1], 10, 64)
+		}
+		result[prefix] = values
+	}
+	return result
+}
+
+// readProcTable parses the header/value line pairs of /proc/net/netstat and /proc/net/snmp
+func readProcTable(path string) map[string]map[string]int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	result := make(map[string]map[string]int64)
+	headers := make(map[string][]string)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		prefix, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		names, seen := headers[prefix]
+		if !seen {
+			headers[prefix] = fields
+			continue
+		}
+
+		values := make(map[string]int64, len(names))
+		for i := 0; i < len(names) && i < len(fields); i++ {
+			values[names[i]], _ = strconv.ParseInt(fields[i], 10, 64)
+		}
+		result[prefix] = values
+		delete(headers, prefix)
+	}
+	return result
+}
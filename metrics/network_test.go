@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNetworkStats(t *testing.T) {
+	root := t.TempDir()
+
+	netstat := func(overflows, drops int) string {
+		return "TcpExt: SyncookiesSent ListenOverflows ListenDrops\n" +
+			fmt.Sprintf("TcpExt: 0 %d %d\n", overflows, drops) +
+			"IpExt: InNoRoutes\nIpExt: 0\n"
+	}
+	snmp := func(passive int) string {
+		return "Tcp: RtoAlgorithm MaxConn ActiveOpens PassiveOpens\n" +
+			fmt.Sprintf("Tcp: 1 -1 10 %d\n", passive)
+	}
+
+	writeFixture(t, root, map[string]string{
+		"self/fd/0":    "",
+		"self/fd/1":    "",
+		"self/fd/2":    "",
+		"net/sockstat": "sockets: used 290\nTCP: inuse 5 orphan 1 tw 2 alloc 8 mem 3\nUDP: inuse 1 mem 0\n",
+		"net/netstat":  netstat(100, 100),
+		"net/snmp":     snmp(1000),
+	})
+
+	c := &Collector{procRoot: root}
+	c.updateNetwork()
+
+	if c.network.OpenFDs != 3 {
+		t.Errorf("Expected 3 open FDs, got %d", c.network.OpenFDs)
+	}
+	if c.network.Sockets != 290 || c.network.TCPInUse != 5 || c.network.TCPTimeWait != 2 || c.network.TCPMemPages != 3 {
+		t.Errorf("Unexpected sockstat values: %+v", c.network)
+	}
+	if c.network.ListenDrops != 0 {
+		t.Errorf("First sample should only record a baseline, got %d drops", c.network.ListenDrops)
+	}
+
+	writeFixture(t, root, map[string]string{
+		"net/netstat": netstat(105, 110),
+		"net/snmp":    snmp(1090),
+	})
+	c.updateNetwork()
+
+	if c.network.ListenOverflows != 5 || c.network.ListenDrops != 10 || c.network.PassiveOpens != 90 {
+		t.Errorf("Expected counter deltas 5/10/90, got %+v", c.network)
+	}
+	if ratio := c.network.ListenDropRatio(); ratio != 0.1 {
+		t.Errorf("Expected a listen drop ratio of 0.1, got %f", ratio)
+	}
+}
+
+func TestFDLoad(t *testing.T) {
+	stats := NetworkStats{OpenFDs: 256, FDLimit: 1024}
+	if stats.FDLoad() != 0.25 {
+		t.Errorf("Expected FD load 0.25, got %f", stats.FDLoad())
+	}
+
+	if (NetworkStats{OpenFDs: 10}).FDLoad() != 0 {
+		t.Errorf("Unknown FD limit should report 0")
+	}
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PressureStat is one line of a PSI file; averages are percentages of wall time (0-100)
+type PressureStat struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+
+	//cumulative stall time in microseconds
+	Total uint64
+}
+
+// Pressure holds the time some, or all (full), runnable tasks were stalled on a resource
+type Pressure struct {
+	Some PressureStat
+	Full PressureStat
+}
+
+func readPressure(path string) (Pressure, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Pressure{}, false
+	}
+
+	var p Pressure
+	found := false
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var stat *PressureStat
+		switch fields[0] {
+		case "some":
+			stat = &p.Some
+		case "full":
+			stat = &p.Full
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+
+			switch key {
+			case "avg10":
+				stat.Avg10, _ = strconv.ParseFloat(value, 64)
+			case "avg60":
+				stat.Avg60, _ = strconv.ParseFloat(value, 64)
+			case "avg300":
+				stat.Avg300, _ = strconv.ParseFloat(value, 64)
+			case "total":
+				stat.Total, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+		found = true
+	}
+
+	return p, found
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPressure(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"pressure/memory": "some avg10=12.50 avg60=4.00 avg300=1.00 total=123456\n" +
+			"full avg10=2.25 avg60=0.50 avg300=0.10 total=6543\n",
+		"pressure/cpu": "some avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+	})
+
+	p, ok := readPressure(filepath.Join(root, "pressure", "memory"))
+	if !ok {
+		t.Fatalf("Expected memory pressure to be read")
+	}
+	if p.Some.Avg10 != 12.5 || p.Some.Avg60 != 4 || p.Some.Total != 123456 {
+		t.Errorf("Unexpected some pressure: %+v", p.Some)
+	}
+	if p.Full.Avg10 != 2.25 || p.Full.Avg300 != 0.1 {
+		t.Errorf("Unexpected full pressure: %+v", p.Full)
+	}
+
+	if _, ok := readPressure(filepath.Join(root, "pressure", "io")); ok {
+		t.Errorf("Missing PSI file should not be reported as read")
+	}
+}
+
+func TestCollectorPressureFromCgroup(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"cgroup.controllers": "cpu memory io\n",
+		"cpu.pressure":       "some avg10=30.00 avg60=0.00 avg300=0.00 total=10\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+	})
+
+	c := &Collector{procRoot: t.TempDir(), cgroup: detectCgroup(root)}
+	c.updatePressure()
+
+	if c.cpuPSI.Some.Avg10 != 30 {
+		t.Errorf("Expected CPU pressure to come from the cgroup, got %+v", c.cpuPSI)
+	}
+}
+
+func TestCollectorPressureFallsBackToProc(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"cgroup.controllers": "cpu memory io\n",
+		"cpu.pressure":       "some avg10=30.00 avg60=0.00 avg300=0.00 total=10\n",
+	})
+
+	proc := t.TempDir()
+	writeFixture(t, proc, map[string]string{
+		"pressure/memory": "some avg10=12.50 avg60=0.00 avg300=0.00 total=10\nfull avg10=5.00 avg60=0.00 avg300=0.00 total=5\n",
+	})
+
+	c := &Collector{procRoot: proc, cgroup: detectCgroup(root)}
+	c.updatePressure()
+
+	if c.cpuPSI.Some.Avg10 != 30 {
+		t.Errorf("Expected CPU pressure to come from the cgroup, got %+v", c.cpuPSI)
+	}
+	if c.memoryPSI.Some.Avg10 != 12.5 || c.memoryPSI.Full.Avg10 != 5 {
+		t.Errorf("Expected memory pressure to fall back to /proc/pressure, got %+v", c.memoryPSI)
+	}
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"math"
+	rtmetrics "runtime/metrics"
+	"time"
+)
+
+// RuntimeStats are Go runtime health signals; rates and percentiles cover the last collection interval
+type RuntimeStats struct {
+	//share of the process's CPU time spent on garbage collection (0.0-1.0)
+	GCCPUFraction float64
+
+	//99th percentile stop-the-world pause for GC
+	GCPauseP99 time.Duration
+
+	//time goroutines spent runnable before being scheduled
+	SchedLatencyP50 time.Duration
+	SchedLatencyP90 time.Duration
+	SchedLatencyP99 time.Duration
+
+	//memory mapped by the runtime over GOMEMLIMIT, 0 when no limit is set
+	HeapLimitRatio float64
+
+	//live heap objects over all memory mapped by the runtime
+	HeapInUseRatio float64
+
+	Goroutines int
+}
+
+const (
+	gcCPUMetric        = "/cpu/classes/gc/total:cpu-seconds"
+	totalCPUMetric     = "/cpu/classes/total:cpu-seconds"
+	gcPausesMetric     = "/sched/pauses/total/gc:seconds"
+	schedLatencyMetric = "/sched/latencies:seconds"
+	memLimitMetric     = "/gc/gomemlimit:bytes"
+	heapObjectsMetric  = "/memory/classes/heap/objects:bytes"
+	heapReleasedMetric = "/memory/classes/heap/released:bytes"
+	totalMemoryMetric  = "/memory/classes/total:bytes"
+	goroutinesMetric   = "/sched/goroutines:goroutines"
+)
+
+// runtimeSampler reads runtime/metrics, which unlike ReadMemStats does not stop the world
+type runtimeSampler struct {
+	samples []rtmetrics.Sample
+
+	lastGCCPU     float64
+	lastTotalCPU  float64
+	lastPauses    []uint64
+	lastLatencies []uint64
+}
+
+func newRuntimeSampler() *runtimeSampler {
+	names := []string{
+		gcCPUMetric, totalCPUMetric, gcPausesMetric, schedLatencyMetric, memLimitMetric,
+		heapObjectsMetric, heapReleasedMetric, totalMemoryMetric, goroutinesMetric,
+	}
+
+	samples := make([]rtmetrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+
+	return &runtimeSampler{samples: samples}
+}
+
+func (r *runtimeSampler) read() RuntimeStats {
+	rtmetrics.Read(r.samples)
+
+	values := make(map[string]rtmetrics.Value, len(r.samples))
+	for _, sample := range r.samples {
+		values[sample.Name] = sample.Value
+	}
+
+	var stats RuntimeStats
+
+	gcCPU := float64Value(values[gcCPUMetric])
+	totalCPU := float64Value(values[totalCPUMetric])
+	if totalCPU > r.lastTotalCPU {
+		stats.GCCPUFraction = (gcCPU - r.lastGCCPU) / (totalCPU - r.lastTotalCPU)
+	}
+	r.lastGCCPU, r.lastTotalCPU = gcCPU, totalCPU
+
+	if h := histogramValue(values[gcPausesMetric]); h != nil {
+		stats.GCPauseP99 = percentile(h, r.lastPauses, 0.99)
+		r.lastPauses = append(r.lastPauses[:0], h.Counts...)
+	}
+
+	if h := histogramValue(values[schedLatencyMetric]); h != nil {
+		stats.SchedLatencyP50 = percentile(h, r.lastLatencies, 0.5)
+		stats.SchedLatencyP90 = percentile(h, r.lastLatencies, 0.9)
+		stats.SchedLatencyP99 = percentile(h, r.lastLatencies, 0.99)
+		r.lastLatencies = append(r.lastLatencies[:0], h.Counts...)
+	}
+
+	total := uint64Value(values[totalMemoryMetric])
+	mapped := total - min64(uint64Value(values[heapReleasedMetric]), total)
+	if limit := uint64Value(values[memLimitMetric]); limit > 0 && limit < math.MaxInt64 {
+		stats.HeapLimitRatio = float64(mapped) / float64(limit)
+	}
+	if mapped > 0 {
+		stats.HeapInUseRatio = float64(uint64Value(values[heapObjectsMetric])) / float64(mapped)
+	}
+
+	stats.Goroutines = int(uint64Value(values[goroutinesMetric]))
+
+	return stats
+}
+
+// percentile returns the upper bound of the bucket holding p of the observations
+// recorded since previous, a copy of the cumulative counts at the last read
+func percentile(h *rtmetrics.Float64Histogram, previous []uint64, p float64) time.Duration {
+	deltas := make([]uint64, len(h.Counts))
+	var total uint64
+	for i, count := range h.Counts {
+		deltas[i] = count
+		if len(previous) == len(h.Counts) {
+			deltas[i] -= previous[i]
+		}
+		total += deltas[i]
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(p * float64(total)))
+	var seen uint64
+	for i, count := range deltas {
+		seen += count
+		if seen >= rank {
+			bound := h.Buckets[i+1]
+			if math.IsInf(bound, 1) {
+				bound = h.Buckets[i]
+			}
+			return time.Duration(bound * float64(time.Second))
+		}
+	}
+
+	return 0
+}
+
+func float64Value(v rtmetrics.Value) float64 {
+	if v.Kind() != rtmetrics.KindFloat64 {
+		return 0
+	}
+	return v.Float64()
+}
+
+func uint64Value(v rtmetrics.Value) uint64 {
+	if v.Kind() != rtmetrics.KindUint64 {
+		return 0
+	}
+	return v.Uint64()
+}
+
+func histogramValue(v rtmetrics.Value) *rtmetrics.Float64Histogram {
+	if v.Kind() != rtmetrics.KindFloat64Histogram {
+		return nil
+	}
+	return v.Float64Histogram()
+}
+
+func min64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"math"
+	"runtime"
+	"runtime/debug"
+	rtmetrics "runtime/metrics"
+	"testing"
+	"time"
+)
+
+func TestRuntimeSampler(t *testing.T) {
+	previous := debug.SetMemoryLimit(1 << 40)
+	defer debug.SetMemoryLimit(previous)
+
+	sampler := newRuntimeSampler()
+	sampler.read()
+
+	garbage := make([][]byte, 0, 100)
+	for range 100 {
+		garbage = append(garbage, make([]byte, 1<<16))
+	}
+	runtime.GC()
+	runtime.KeepAlive(garbage)
+
+	stats := sampler.read()
+
+	if stats.Goroutines < 1 {
+		t.Errorf("Expected at least one goroutine, got %d", stats.Goroutines)
+	}
+	if stats.HeapInUseRatio <= 0 || stats.HeapInUseRatio > 1 {
+		t.Errorf("HeapInUseRatio should be between 0 and 1, got %f", stats.HeapInUseRatio)
+	}
+	if stats.HeapLimitRatio <= 0 || stats.HeapLimitRatio > 1 {
+		t.Errorf("HeapLimitRatio should be set under GOMEMLIMIT, got %f", stats.HeapLimitRatio)
+	}
+	if stats.GCCPUFraction < 0 || stats.GCCPUFraction > 1 {
+		t.Errorf("GCCPUFraction should be between 0 and 1, got %f", stats.GCCPUFraction)
+	}
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	h := &rtmetrics.Float64Histogram{
+		Counts:  []uint64{10, 10, 5, 1},
+		Buckets: []float64{0, 0.001, 0.01, 0.1, math.Inf(1)},
+	}
+
+	if p := percentile(h, nil, 0.5); p != time.Millisecond*10 {
+		t.Errorf("Expected p50 of 10ms, got %v", p)
+	}
+	if p := percentile(h, nil, 0.99); p != time.Millisecond*100 {
+		t.Errorf("Expected p99 to use the lower bound of the open bucket, got %v", p)
+	}
+
+	//only the observations since the previous read count
+	if p := percentile(h, []uint64{10, 10, 0, 1}, 0.5); p != time.Millisecond*100 {
+		t.Errorf("Expected p50 of the delta to be 100ms, got %v", p)
+	}
+	if p := percentile(h, []uint64{10, 10, 5, 1}, 0.5); p != 0 {
+		t.Errorf("Expected 0 with no new observations, got %v", p)
+	}
+}
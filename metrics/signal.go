@@ -0,0 +1,36 @@
+package metrics
+
+// SignalSource is any saturation reading the limiter can adapt to, such as queue depth,
+// connection pool usage or downstream latency relative to its budget
+type SignalSource interface {
+	Name() string
+
+	//saturation between 0.0 (idle) and 1.0 (saturated)
+	Value() float64
+
+	//false when the reading cannot currently be trusted, in which case it is ignored
+	Healthy() bool
+}
+
+// NewSignal adapts plain functions to a SignalSource; a nil healthy func is always healthy
+func NewSignal(name string, value func() float64, healthy func() bool) SignalSource {
+	return &signalFunc{name: name, value: value, healthy: healthy}
+}
+
+type signalFunc struct {
+	name    string
+	value   func() float64
+	healthy func() bool
+}
+
+func (s *signalFunc) Name() string {
+	return s.name
+}
+
+func (s *signalFunc) Value() float64 {
+	return s.value()
+}
+
+func (s *signalFunc) Healthy() bool {
+	return s.healthy == nil || s.healthy()
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Sketch is a mergeable DDSketch-style quantile summary: every quantile it reports is within
+// the configured relative accuracy of the true value. It is not safe for concurrent use.
+type Sketch struct {
+	gamma    float64
+	logGamma float64
+
+	buckets map[int]uint64
+	zeros   uint64
+	count   uint64
+	sum     float64
+	min     float64
+	max     float64
+}
+
+// values at or below this are counted as zero rather than given a log bucket
+const sketchMinValue = 1e-9
+
+func NewSketch(relativeAccuracy float64) *Sketch {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		relativeAccuracy = 0.01
+	}
+
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &Sketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		buckets:  make(map[int]uint64),
+	}
+}
+
+// Add records a non-negative value; negative values are ignored
+func (s *Sketch) Add(value float64) {
+	if value < 0 || math.IsNaN(value) {
+		return
+	}
+
+	if s.count == 0 || value < s.min {
+		s.min = value
+	}
+	if s.count == 0 || value > s.max {
+		s.max = value
+	}
+	s.count++
+	s.sum += value
+
+	if value <= sketchMinValue {
+		s.zeros++
+		return
+	}
+	s.buckets[int(math.Ceil(math.Log(value)/s.logGamma))]++
+}
+
+// Merge adds the observations of other, which must have been created with the same accuracy
+func (s *Sketch) Merge(other *Sketch) {
+	if other == nil || other.count == 0 {
+		return
+	}
+
+	for index, n := range other.buckets {
+		s.buckets[index] += n
+	}
+
+	if s.count == 0 || other.min < s.min {
+		s.min = other.min
+	}
+	if s.count == 0 || other.max > s.max {
+		s.max = other.max
+	}
+	s.zeros += other.zeros
+	s.count += other.count
+	s.sum += other.sum
+}
+
+// Quantile returns the value at q (0.0-1.0), or 0 when the sketch is empty
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+
+	if q <= 0 {
+		return s.min
+	}
+	if q >= 1 {
+		return s.max
+	}
+
+	rank := uint64(q * float64(s.count-1))
+
+	if rank < s.zeros {
+		return s.min
+	}
+
+	indexes := make([]int, 0, len(s.buckets))
+	for index := range s.buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	seen := s.zeros
+	for _, index := range indexes {
+		seen += s.buckets[index]
+		if seen > rank {
+			value := 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+			return math.Max(s.min, math.Min(value, s.max))
+		}
+	}
+
+	return s.max
+}
+
+func (s *Sketch) Count() uint64 {
+	return s.count
+}
+
+func (s *Sketch) Sum() float64 {
+	return s.sum
+}
+
+func (s *Sketch) Mean() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.sum / float64(s.count)
+}
+
+func (s *Sketch) Reset() {
+	clear(s.buckets)
+	s.zeros = 0
+	s.count = 0
+	s.sum = 0
+	s.min = 0
+	s.max = 0
+}
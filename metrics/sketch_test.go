@@ -0,0 +1,61 @@
+package metrics_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/estavadormir/adaptlimit/metrics"
+)
+
+func TestSketchQuantiles(t *testing.T) {
+	sketch := metrics.NewSketch(0.01)
+	for i := 1; i <= 1000; i++ {
+		sketch.Add(float64(i) / 1e6) // 1us to 1ms
+	}
+
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		want := math.Floor(q*999+1) / 1e6
+		got := sketch.Quantile(q)
+		if math.Abs(got-want)/want > 0.01 {
+			t.Errorf("Quantile %.2f should be within 1%% of %g, got %g", q, want, got)
+		}
+	}
+
+	if sketch.Count() != 1000 {
+		t.Errorf("Expected 1000 observations, got %d", sketch.Count())
+	}
+	if mean := sketch.Mean(); math.Abs(mean-500.5e-6) > 1e-12 {
+		t.Errorf("Expected an exact mean of 500.5us, got %g", mean)
+	}
+}
+
+func TestSketchMergeAndReset(t *testing.T) {
+	fast := metrics.NewSketch(0.01)
+	slow := metrics.NewSketch(0.01)
+
+	for range 99 {
+		fast.Add(0.001)
+	}
+	slow.Add(0)
+	slow.Add(2)
+
+	fast.Merge(slow)
+
+	if fast.Count() != 101 {
+		t.Errorf("Expected 101 observations after merge, got %d", fast.Count())
+	}
+	if p := fast.Quantile(0); p != 0 {
+		t.Errorf("Expected the zero observation as the minimum, got %g", p)
+	}
+	if p := fast.Quantile(1); p != 2 {
+		t.Errorf("Expected the slow observation as the maximum, got %g", p)
+	}
+	if p := fast.Quantile(0.5); math.Abs(p-0.001)/0.001 > 0.01 {
+		t.Errorf("Expected the median near 1ms, got %g", p)
+	}
+
+	fast.Reset()
+	if fast.Count() != 0 || fast.Quantile(0.99) != 0 {
+		t.Errorf("Reset should empty the sketch")
+	}
+}
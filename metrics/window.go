@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// View selects how the recent samples of a reading are summarised
+type View int
+
+const (
+	ViewLatest View = iota
+	ViewEWMA
+	ViewMean
+	ViewMin
+	ViewMax
+	ViewP50
+	ViewP90
+	ViewP99
+)
+
+// Reading names a windowed value of the collector
+type Reading int
+
+const (
+	ReadingCPU Reading = iota
+	ReadingMemory
+	ReadingProcessCPU
+	ReadingSystemCPU
+)
+
+// window is a fixed-size ring buffer of samples with a running EWMA
+type window struct {
+	samples []float64
+	next    int
+	count   int
+	alpha   float64
+	ewma    float64
+}
+
+func newWindow(size int, alpha float64) *window {
+	if size < 1 {
+		size = 1
+	}
+
+	return &window{
+		samples: make([]float64, size),
+		alpha:   alpha,
+	}
+}
+
+func (w *window) add(value float64) {
+	if w.count == 0 {
+		w.ewma = value
+	} else {
+		w.ewma = w.alpha*value + (1-w.alpha)*w.ewma
+	}
+
+	w.samples[w.next] = value
+	w.next = (w.next + 1) % len(w.samples)
+	if w.count < len(w.samples) {
+		w.count++
+	}
+}
+
+func (w *window) view(v View) float64 {
+	if w.count == 0 {
+		return 0
+	}
+
+	switch v {
+	case ViewEWMA:
+		return w.ewma
+	case ViewMean:
+		sum := 0.0
+		for _, s := range w.values() {
+			sum += s
+		}
+		return sum / float64(w.count)
+	case ViewMin:
+		return w.sorted()[0]
+	case ViewMax:
+		return w.sorted()[w.count-1]
+	case ViewP50:
+		return w.percentile(0.5)
+	case ViewP90:
+		return w.percentile(0.9)
+	case ViewP99:
+		return w.percentile(0.99)
+	default:
+		return w.samples[(w.next-1+len(w.samples))%len(w.samples)]
+	}
+}
+
+func (w *window) values() []float64 {
+	if w.count < len(w.samples) {
+		return w.samples[:w.count]
+	}
+	return w.samples
+}
+
+func (w *window) sorted() []float64 {
+	values := append([]float64(nil), w.values()...)
+	sort.Float64s(values)
+	return values
+}
+
+// percentile uses the nearest-rank method over the samples in the window
+func (w *window) percentile(p float64) float64 {
+	values := w.sorted()
+	rank := int(math.Ceil(p*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return values[rank]
+}
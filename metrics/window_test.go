@@ -0,0 +1,44 @@
+package metrics
+
+import "testing"
+
+func TestWindowViews(t *testing.T) {
+	w := newWindow(4, 0.5)
+
+	if w.view(ViewLatest) != 0 || w.view(ViewP99) != 0 {
+		t.Errorf("Empty window should report 0")
+	}
+
+	for _, v := range []float64{0.9, 0.1, 0.2, 0.3, 0.4} {
+		w.add(v)
+	}
+
+	//0.9 has been pushed out of the window but still shapes the EWMA
+	cases := map[View]float64{
+		ViewLatest: 0.4,
+		ViewMin:    0.1,
+		ViewMax:    0.4,
+		ViewP50:    0.2,
+		ViewP99:    0.4,
+		ViewEWMA:   0.3625,
+	}
+	for view, want := range cases {
+		if got := w.view(view); got < want-1e-9 || got > want+1e-9 {
+			t.Errorf("View %d: expected %f, got %f", view, want, got)
+		}
+	}
+
+	if mean := w.view(ViewMean); mean < 0.25-1e-9 || mean > 0.25+1e-9 {
+		t.Errorf("Expected mean 0.25, got %f", mean)
+	}
+}
+
+func TestWindowPartial(t *testing.T) {
+	w := newWindow(10, 0.3)
+	w.add(0.5)
+	w.add(0.7)
+
+	if w.view(ViewMin) != 0.5 || w.view(ViewMax) != 0.7 {
+		t.Errorf("Partially filled window should only use its samples, got min=%f max=%f", w.view(ViewMin), w.view(ViewMax))
+	}
+}
@@ -0,0 +1,122 @@
+package predict
+
+import (
+	"math"
+)
+
+// Interval is a point forecast with the range the next value should fall in at some confidence
+type Interval struct {
+	Value float64
+	Lower float64
+	Upper float64
+}
+
+// Accuracy summarises how far recent PredictNext forecasts, from whichever model was in use
+// at the time, were from the values that arrived
+type Accuracy struct {
+	//mean absolute error
+	MAE float64
+
+	//mean absolute percentage error (0.0-1.0+), skipping actual values of zero
+	MAPE float64
+
+	Samples int
+}
+
+type forecastError struct {
+	absolute   float64
+	percentage float64
+	hasPercent bool
+}
+
+// WithErrorWindow sets how many past forecasts Accuracy averages over
+func WithErrorWindow(size int) Option {
+	return func(f *Forecaster) {
+		if size > 0 {
+			f.errorWindow = size
+		}
+	}
+}
+
+// PredictInterval returns PredictNext with a band covering confidence (e.g. 0.8 or 0.95) of
+// outcomes, assuming normally distributed one-step residuals of the same model
+func (f *Forecaster) PredictInterval(confidence float64) Interval {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	history := f.completed()
+	value := f.nextForecast(history)
+
+	if confidence <= 0 || confidence >= 1 {
+		return Interval{Value: value, Lower: value, Upper: value}
+	}
+
+	spread := math.Sqrt2 * math.Erfinv(confidence) * f.residualStdDev(history)
+	return Interval{Value: value, Lower: value - spread, Upper: value + spread}
+}
+
+// Accuracy reports the rolling error of one-step forecasts as new points were added
+func (f *Forecaster) Accuracy() Accuracy {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var acc Accuracy
+	percentages := 0
+	for _, e := range f.errors {
+		acc.MAE += e.absolute
+		if e.hasPercent {
+			acc.MAPE += e.percentage
+			percentages++
+		}
+	}
+
+	acc.Samples = len(f.errors)
+	if acc.Samples > 0 {
+		acc.MAE /= float64(acc.Samples)
+	}
+	if percentages > 0 {
+		acc.MAPE /= float64(percentages)
+	}
+	return acc
+}
+
+// recordError scores the forecast that history made for actual, the value that followed it
+func (f *Forecaster) recordError(history []DataPoint, actual float64) {
+	if len(history) < 2 {
+		return
+	}
+
+	forecast := f.nextForecast(history)
+	e := forecastError{absolute: math.Abs(actual - forecast)}
+	if actual != 0 {
+		e.percentage = e.absolute / math.Abs(actual)
+		e.hasPercent = true
+	}
+
+	f.errors = append(f.errors, e)
+	if len(f.errors) > f.errorWindow {
+		f.errors = f.errors[len(f.errors)-f.errorWindow:]
+	}
+}
+
+// residualStdDev is the root mean square of the one-step errors PredictNext would have made
+// for the most recent points: over the holdout for a selected model, the error window otherwise
+func (f *Forecaster) residualStdDev(history []DataPoint) float64 {
+	if len(history) < 3 {
+		return 0
+	}
+
+	window := f.errorWindow
+	if f.selected {
+		window = f.holdout
+	}
+
+	sumSquares, n := 0.0, 0
+	for t := max(2, len(history)-window); t < len(history); t++ {
+		residual := history[t].Value - f.nextForecast(history[:t])
+		sumSquares += residual * residual
+		n++
+	}
+
+	return math.Sqrt(sumSquares / float64(n))
+}
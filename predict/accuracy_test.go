@@ -0,0 +1,93 @@
+package predict_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+func TestPredictInterval(t *testing.T) {
+	f := predict.NewForecaster()
+	for i := range 100 {
+		f.AddDataPoint(100 + 10*float64(i%2*2-1))
+	}
+
+	narrow := f.PredictInterval(0.8)
+	wide := f.PredictInterval(0.95)
+
+	if narrow.Lower > narrow.Value || narrow.Upper < narrow.Value {
+		t.Errorf("Expected the interval to contain the forecast, got %+v", narrow)
+	}
+	if wide.Upper-wide.Lower <= narrow.Upper-narrow.Lower {
+		t.Errorf("Expected the 95%% interval to be wider than the 80%%, got %+v and %+v", wide, narrow)
+	}
+	if narrow.Lower > 90 || narrow.Upper < 110 {
+		t.Errorf("Expected the 80%% interval to span the observed range, got %+v", narrow)
+	}
+
+	flat := predict.NewForecaster()
+	for range 10 {
+		flat.AddDataPoint(50)
+	}
+	if got := flat.PredictInterval(0.95); got.Lower != 50 || got.Upper != 50 {
+		t.Errorf("Expected no spread for a constant series, got %+v", got)
+	}
+}
+
+func TestForecasterAccuracy(t *testing.T) {
+	f := predict.NewForecaster(predict.WithErrorWindow(5))
+	if acc := f.Accuracy(); acc.Samples != 0 {
+		t.Errorf("Expected no samples before any forecasts, got %+v", acc)
+	}
+
+	for range 10 {
+		f.AddDataPoint(100)
+	}
+	if acc := f.Accuracy(); acc.Samples != 5 || acc.MAE != 0 || acc.MAPE != 0 {
+		t.Errorf("Expected perfect forecasts over a window of 5, got %+v", acc)
+	}
+
+	f.AddDataPoint(150)
+	acc := f.Accuracy()
+	if math.Abs(acc.MAE-10) > 1e-9 || math.Abs(acc.MAPE-(50.0/150)/5) > 1e-9 {
+		t.Errorf("Expected MAE 10 and MAPE %.4f, got %+v", (50.0/150)/5, acc)
+	}
+}
+
+func TestForecasterAccuracyBuckets(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 0))
+
+	//only completed buckets are scored, not the one still filling
+	for minute := range 5 {
+		for second := range 10 {
+			f.AddDataPointAt(start.Add(time.Duration(minute)*time.Minute+time.Duration(second)*time.Second), 1)
+		}
+	}
+
+	acc := f.Accuracy()
+	if acc.Samples != 2 || acc.MAE != 0 {
+		t.Errorf("Expected 2 perfect forecasts of completed buckets, got %+v", acc)
+	}
+}
+
+func TestPredictIntervalForgetsOldErrors(t *testing.T) {
+	f := predict.NewForecaster(predict.WithErrorWindow(20))
+
+	//an old level shift should not widen the band once demand has settled
+	for range 50 {
+		f.AddDataPoint(100)
+	}
+	for range 10 {
+		f.AddDataPoint(1000)
+	}
+	for range 100 {
+		f.AddDataPoint(100)
+	}
+
+	if got := f.PredictInterval(0.95); got.Value != 100 || got.Upper-got.Lower != 0 {
+		t.Errorf("Expected a flat interval at 100 after recovery, got %+v", got)
+	}
+}
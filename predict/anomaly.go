@@ -0,0 +1,236 @@
+package predict
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Detector selects how a new point is compared with the history before it
+type Detector int
+
+const (
+	//DetectZScore scores the distance from the recent mean in standard deviations
+	DetectZScore Detector = iota
+
+	//DetectMAD scores the distance from the recent median in scaled median absolute deviations,
+	//so earlier outliers do not mask new ones
+	DetectMAD
+
+	//DetectSeasonal applies MAD to the change since the same point one season earlier,
+	//falling back to DetectMAD until two seasons of history exist
+	DetectSeasonal
+)
+
+type AnomalyKind int
+
+const (
+	//AnomalySpike is demand well above normal, e.g. abuse or scraping
+	AnomalySpike AnomalyKind = iota
+
+	//AnomalyDrop is demand well below normal, e.g. an upstream outage
+	AnomalyDrop
+)
+
+func (k AnomalyKind) String() string {
+	if k == AnomalyDrop {
+		return "drop"
+	}
+	return "spike"
+}
+
+type Anomaly struct {
+	Timestamp time.Time
+	Value     float64
+	Expected  float64
+
+	//signed distance from Expected in units of the detector's spread
+	Score float64
+
+	Kind     AnomalyKind
+	Detector Detector
+}
+
+// minimum number of earlier points before anything is flagged
+const minAnomalyHistory = 10
+
+// scales the median absolute deviation to a standard deviation for normal data
+const madScale = 1.4826
+
+// WithAnomalyDetector flags points scoring beyond threshold against the last window points
+func WithAnomalyDetector(detector Detector, threshold float64, window int) Option {
+	return func(f *Forecaster) {
+		f.detector = detector
+		if threshold > 0 {
+			f.anomalyThreshold = threshold
+		}
+		if window >= minAnomalyHistory {
+			f.anomalyWindow = window
+		}
+	}
+}
+
+// WithAnomalyHandler is called, outside the forecaster's lock, for every anomalous point as it
+// is completed: each appended point, or each bucket once a newer one opens
+func WithAnomalyHandler(handler func(Anomaly)) Option {
+	return func(f *Forecaster) {
+		f.onAnomaly = handler
+	}
+}
+
+// Detect checks the newest completed point against the history before it
+func (f *Forecaster) Detect() (Anomaly, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	history := f.completed()
+	if len(history) == 0 {
+		return Anomaly{}, false
+	}
+
+	return f.detect(history[:len(history)-1], history[len(history)-1])
+}
+
+// observe scores a completed point against the history that preceded it, queueing any
+// events for the handlers
+func (f *Forecaster) observe(history []DataPoint, point DataPoint) {
+	if f.importing {
+		return
+	}
+
+	f.recordError(history, point.Value)
+
+	if f.onAnomaly != nil {
+		if anomaly, ok := f.detect(history, point); ok {
+			f.pendingAnomalies = append(f.pendingAnomalies, anomaly)
+		}
+	}
+
+	if f.changeDetection {
+		f.trackChange(point)
+	}
+
+	f.sinceSelection++
+	f.sinceSeason++
+}
+
+func (f *Forecaster) takePending() ([]Anomaly, []ChangePoint) {
+	anomalies, changes := f.pendingAnomalies, f.pendingChanges
+	f.pendingAnomalies, f.pendingChanges = nil, nil
+	return anomalies, changes
+}
+
+func (f *Forecaster) detect(history []DataPoint, point DataPoint) (Anomaly, bool) {
+	if len(history) < minAnomalyHistory {
+		return Anomaly{}, false
+	}
+
+	detector := f.detector
+	value, expected, score := point.Value, 0.0, 0.0
+
+	switch detector {
+	case DetectZScore:
+		recent := recentValues(history, f.anomalyWindow)
+		expected = mean(recent)
+		score = standardScore(value-expected, stdDev(recent, expected))
+	case DetectSeasonal:
+		if season := f.season(); season > 1 && len(history) >= 2*season {
+			expected, score = seasonalScore(history, value, season, f.anomalyWindow)
+			break
+		}
+		detector = DetectMAD
+		fallthrough
+	default:
+		recent := recentValues(history, f.anomalyWindow)
+		expected = median(recent)
+		score = standardScore(value-expected, madScale*medianDeviation(recent, expected))
+	}
+
+	if math.Abs(score) < f.anomalyThreshold {
+		return Anomaly{}, false
+	}
+
+	anomaly := Anomaly{
+		Timestamp: point.Timestamp,
+		Value:     value,
+		Expected:  expected,
+		Score:     score,
+		Kind:      AnomalySpike,
+		Detector:  detector,
+	}
+	if score < 0 {
+		anomaly.Kind = AnomalyDrop
+	}
+	return anomaly, true
+}
+
+// seasonalScore scores the change in value since one season earlier against recent such changes
+func seasonalScore(history []DataPoint, value float64, season, window int) (expected, score float64) {
+	values := make([]float64, len(history), len(history)+1)
+	for i, point := range history {
+		values[i] = point.Value
+	}
+	values = append(values, value)
+
+	changes := make([]float64, 0, len(values)-season)
+	for i := season; i < len(values); i++ {
+		changes = append(changes, values[i]-values[i-season])
+	}
+
+	latest := changes[len(changes)-1]
+	recent := changes[:len(changes)-1]
+	if len(recent) > window {
+		recent = recent[len(recent)-window:]
+	}
+
+	typical := median(recent)
+	expected = values[len(values)-1-season] + typical
+	return expected, standardScore(latest-typical, madScale*medianDeviation(recent, typical))
+}
+
+// standardScore divides deviation by spread, treating any deviation from a flat series as infinite
+func standardScore(deviation, spread float64) float64 {
+	if spread > 0 {
+		return deviation / spread
+	}
+	if deviation == 0 {
+		return 0
+	}
+	return math.Copysign(math.Inf(1), deviation)
+}
+
+func recentValues(history []DataPoint, window int) []float64 {
+	start := max(0, len(history)-window)
+	values := make([]float64, 0, len(history)-start)
+	for _, point := range history[start:] {
+		values = append(values, point.Value)
+	}
+	return values
+}
+
+func stdDev(values []float64, mean float64) float64 {
+	sumSquares := 0.0
+	for _, v := range values {
+		sumSquares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sumSquares / float64(len(values)))
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func medianDeviation(values []float64, center float64) float64 {
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - center)
+	}
+	return median(deviations)
+}
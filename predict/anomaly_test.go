@@ -0,0 +1,144 @@
+package predict_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+func steady(i int) float64 {
+	return 100 + float64(i%5) - 2
+}
+
+func TestDetectZScoreSpike(t *testing.T) {
+	f := predict.NewForecaster(predict.WithAnomalyDetector(predict.DetectZScore, 3, 30))
+	for i := range 30 {
+		f.AddDataPoint(steady(i))
+	}
+
+	if anomaly, ok := f.Detect(); ok {
+		t.Fatalf("Expected steady traffic to be normal, got %+v", anomaly)
+	}
+
+	f.AddDataPoint(150)
+	anomaly, ok := f.Detect()
+	if !ok || anomaly.Kind != predict.AnomalySpike || anomaly.Detector != predict.DetectZScore {
+		t.Fatalf("Expected a z-score spike, got %+v (%v)", anomaly, ok)
+	}
+	if anomaly.Value != 150 || math.Abs(anomaly.Expected-100) > 1 {
+		t.Errorf("Expected 150 against about 100, got %+v", anomaly)
+	}
+}
+
+func TestDetectMADDrop(t *testing.T) {
+	f := predict.NewForecaster(predict.WithAnomalyDetector(predict.DetectMAD, 3.5, 30))
+	for i := range 30 {
+		f.AddDataPoint(steady(i))
+	}
+
+	//an earlier outlier does not mask the next one
+	f.AddDataPoint(1000)
+	f.AddDataPoint(10)
+
+	anomaly, ok := f.Detect()
+	if !ok || anomaly.Kind != predict.AnomalyDrop || anomaly.Score >= 0 {
+		t.Errorf("Expected a drop, got %+v (%v)", anomaly, ok)
+	}
+}
+
+func TestDetectSeasonal(t *testing.T) {
+	pattern := []float64{50, 100, 200, 100}
+	f := predict.NewForecaster(
+		predict.WithSeasonLength(4),
+		predict.WithAnomalyDetector(predict.DetectSeasonal, 3.5, 30))
+	for i := range 40 {
+		f.AddDataPoint(pattern[i%4] + float64(i%3))
+	}
+
+	//the daily peak is expected, the same level in a trough is not
+	f.AddDataPoint(51)
+	if anomaly, ok := f.Detect(); ok {
+		t.Errorf("Expected the seasonal trough to be normal, got %+v", anomaly)
+	}
+
+	f.AddDataPoint(200)
+	anomaly, ok := f.Detect()
+	if !ok || anomaly.Kind != predict.AnomalySpike || anomaly.Detector != predict.DetectSeasonal {
+		t.Errorf("Expected a seasonal spike, got %+v (%v)", anomaly, ok)
+	}
+}
+
+func TestAnomalyHandler(t *testing.T) {
+	var anomalies []predict.Anomaly
+	f := predict.NewForecaster(
+		predict.WithAnomalyDetector(predict.DetectMAD, 3.5, 30),
+		predict.WithAnomalyHandler(func(a predict.Anomaly) {
+			anomalies = append(anomalies, a)
+		}))
+
+	for i := range 20 {
+		f.AddDataPoint(steady(i))
+	}
+	f.AddDataPoint(500)
+	f.AddDataPoint(steady(0))
+
+	if len(anomalies) != 1 || anomalies[0].Value != 500 {
+		t.Errorf("Expected one anomaly for the spike, got %+v", anomalies)
+	}
+}
+
+func TestAnomalyHandlerBuckets(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var anomalies []predict.Anomaly
+	f := predict.NewForecaster(
+		predict.WithBuckets(time.Minute, 0),
+		predict.WithAnomalyHandler(func(a predict.Anomaly) {
+			anomalies = append(anomalies, a)
+		}))
+
+	for minute := range 15 {
+		requests := 10
+		if minute == 12 {
+			requests = 100
+		}
+		for i := range requests {
+			f.AddDataPointAt(start.Add(time.Duration(minute)*time.Minute+time.Duration(i)*time.Second/2), 1)
+		}
+	}
+
+	if len(anomalies) != 1 || !anomalies[0].Timestamp.Equal(start.Add(12*time.Minute)) {
+		t.Errorf("Expected the busy minute to be flagged once complete, got %+v", anomalies)
+	}
+}
+
+func TestDetectSeasonalAfterIdleGap(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var anomalies []predict.Anomaly
+	f := predict.NewForecaster(
+		predict.WithBuckets(time.Minute, 2*24*time.Hour),
+		predict.WithAnomalyDetector(predict.DetectSeasonal, 3.5, 30),
+		predict.WithAnomalyHandler(func(a predict.Anomaly) {
+			anomalies = append(anomalies, a)
+		}))
+
+	//two days of an hourly cycle, with the season left to detection
+	for i := range 2 * 24 * 60 {
+		f.AddDataPointAt(start.Add(time.Duration(i)*time.Minute), math.Round(100+50*math.Sin(2*math.Pi*float64(i)/60))+float64(i%7))
+	}
+	anomalies = nil
+
+	//a day without requests completes a day of zero buckets in one call
+	began := time.Now()
+	f.AddDataPointAt(start.Add(3*24*time.Hour), 100)
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("Expected the idle day to be scored quickly, took %v", elapsed)
+	}
+
+	if len(anomalies) == 0 || anomalies[0].Kind != predict.AnomalyDrop || anomalies[0].Detector != predict.DetectSeasonal {
+		t.Errorf("Expected the outage to be flagged as a seasonal drop, got %+v", anomalies)
+	}
+}
@@ -0,0 +1,196 @@
+package predict
+
+import (
+	"sort"
+	"time"
+)
+
+// Aggregation decides how the values falling into one bucket are combined
+type Aggregation int
+
+const (
+	//AggregateSum adds the values, suited to counting requests per bucket
+	AggregateSum Aggregation = iota
+
+	//AggregateMean averages the values, suited to gauges such as latency or load
+	AggregateMean
+)
+
+type bucket struct {
+	sum   float64
+	count int64
+}
+
+// WithBuckets accumulates values into fixed-width buckets instead of keeping every raw point.
+// Buckets older than retention behind the newest one are dropped; with no retention the
+// newest maxHistory buckets are kept. The newest bucket is left out of forecasts while it fills.
+// With AggregateSum, intervals without values are kept as zero buckets.
+func WithBuckets(width, retention time.Duration) Option {
+	return func(f *Forecaster) {
+		if width <= 0 {
+			return
+		}
+
+		f.bucketWidth = width
+		if retention > 0 {
+			f.retention = retention
+		}
+	}
+}
+
+func WithAggregation(aggregation Aggregation) Option {
+	return func(f *Forecaster) {
+		f.aggregation = aggregation
+	}
+}
+
+// AddDataPointAt records value as observed at t, so history can be backfilled out of order
+func (f *Forecaster) AddDataPointAt(t time.Time, value float64) {
+	f.mu.Lock()
+
+	if f.bucketWidth > 0 {
+		f.addToBucket(t, value)
+	} else {
+		f.insert(t, value)
+	}
+
+	f.trim()
+	if f.autoSelect && (!f.selected || f.sinceSelection >= f.selectEvery) {
+		f.selectModel()
+	} else if f.sinceSeason >= max(seasonRefreshEvery, len(f.history)/10) {
+		f.refreshSeason()
+	}
+
+	anomalies, changes := f.takePending()
+	onAnomaly, onChange := f.onAnomaly, f.onChange
+	f.mu.Unlock()
+
+	for _, anomaly := range anomalies {
+		onAnomaly(anomaly)
+	}
+	for _, change := range changes {
+		onChange(change)
+	}
+}
+
+func (f *Forecaster) addToBucket(t time.Time, value float64) {
+	start := t.Truncate(f.bucketWidth)
+	if f.retention > 0 && len(f.history) > 0 && start.Before(f.history[len(f.history)-1].Timestamp.Add(-f.retention)) {
+		return
+	}
+
+	i := f.search(start)
+	if i == len(f.history) || !f.history[i].Timestamp.Equal(start) {
+		//opening a new newest bucket completes the one before it, and any idle ones between
+		if i == len(f.history) && i > 0 {
+			f.observe(f.history[:i-1], f.history[i-1])
+			if f.aggregation == AggregateSum {
+				f.fillGap(start)
+			}
+			i = len(f.history)
+		}
+
+		f.history = append(f.history, DataPoint{})
+		f.buckets = append(f.buckets, bucket{})
+		copy(f.history[i+1:], f.history[i:])
+		copy(f.buckets[i+1:], f.buckets[i:])
+		f.history[i] = DataPoint{Timestamp: start}
+		f.buckets[i] = bucket{}
+	}
+
+	b := &f.buckets[i]
+	b.sum += value
+	b.count++
+
+	f.history[i].Value = b.sum
+	if f.aggregation == AggregateMean {
+		f.history[i].Value = b.sum / float64(b.count)
+	}
+}
+
+// insert scores the point when it is the newest; backfilled points are not
+func (f *Forecaster) insert(t time.Time, value float64) {
+	point := DataPoint{Timestamp: t, Value: value}
+
+	i := len(f.history)
+	if i > 0 && t.Before(f.history[i-1].Timestamp) {
+		i = f.search(t)
+	} else {
+		f.observe(f.history, point)
+	}
+
+	f.history = append(f.history, DataPoint{})
+	copy(f.history[i+1:], f.history[i:])
+	f.history[i] = point
+}
+
+// fillGap adds a zero bucket for every interval without values before start, so idle time
+// counts as no demand. Only as many as retention, or maxHistory, would keep are added.
+func (f *Forecaster) fillGap(start time.Time) {
+	keep := f.maxHistory
+	if f.retention > 0 {
+		keep = int(f.retention / f.bucketWidth)
+	}
+
+	next := f.history[len(f.history)-1].Timestamp.Add(f.bucketWidth)
+	if missing := int(start.Sub(next) / f.bucketWidth); missing > keep {
+		next = start.Add(-time.Duration(keep) * f.bucketWidth)
+	}
+
+	for ; next.Before(start); next = next.Add(f.bucketWidth) {
+		f.history = append(f.history, DataPoint{Timestamp: next})
+		f.buckets = append(f.buckets, bucket{})
+		f.observe(f.history[:len(f.history)-1], f.history[len(f.history)-1])
+	}
+}
+
+// search returns the index of the first point at or after t
+func (f *Forecaster) search(t time.Time) int {
+	return sort.Search(len(f.history), func(i int) bool {
+		return !f.history[i].Timestamp.Before(t)
+	})
+}
+
+func (f *Forecaster) trim() {
+	drop := 0
+	if f.bucketWidth > 0 && f.retention > 0 {
+		cutoff := f.history[len(f.history)-1].Timestamp.Add(-f.retention)
+		drop = f.search(cutoff)
+	} else if len(f.history) > f.maxHistory {
+		drop = len(f.history) - f.maxHistory
+	}
+
+	//history from before a detected change point is dropped once, keeping the new regime
+	if !f.truncateBefore.IsZero() {
+		drop = max(drop, f.search(f.truncateBefore))
+		f.truncateBefore = time.Time{}
+	}
+
+	if drop == 0 {
+		return
+	}
+
+	f.history = f.history[drop:]
+	if f.buckets != nil {
+		f.buckets = f.buckets[drop:]
+	}
+}
+
+// completed returns the history without the newest bucket, which is still filling and would
+// read as a drop; every forecast is made from this
+func (f *Forecaster) completed() []DataPoint {
+	if f.bucketWidth > 0 && len(f.history) > 0 {
+		return f.history[:len(f.history)-1]
+	}
+	return f.history
+}
+
+// History returns a copy of the stored points, or of the buckets when bucketing, oldest first
+func (f *Forecaster) History() []DataPoint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	history := make([]DataPoint, len(f.history))
+	copy(history, f.history)
+	return history
+}
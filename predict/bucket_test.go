@@ -0,0 +1,179 @@
+package predict_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+func TestForecasterBucketsSum(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 0))
+
+	for i := range 90 {
+		f.AddDataPointAt(start.Add(time.Duration(i)*2*time.Second), 1)
+	}
+
+	history := f.History()
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", len(history))
+	}
+
+	for i, point := range history {
+		if !point.Timestamp.Equal(start.Add(time.Duration(i) * time.Minute)) {
+			t.Errorf("Bucket %d: expected to start at %v, got %v", i, start.Add(time.Duration(i)*time.Minute), point.Timestamp)
+		}
+		if point.Value != 30 {
+			t.Errorf("Bucket %d: expected 30 requests, got %.0f", i, point.Value)
+		}
+	}
+}
+
+func TestForecasterBucketsMean(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 0), predict.WithAggregation(predict.AggregateMean))
+
+	f.AddDataPointAt(start, 10)
+	f.AddDataPointAt(start.Add(10*time.Second), 20)
+	f.AddDataPointAt(start.Add(70*time.Second), 5)
+
+	history := f.History()
+	if len(history) != 2 || history[0].Value != 15 || history[1].Value != 5 {
+		t.Errorf("Expected bucket means [15 5], got %v", history)
+	}
+}
+
+func TestForecasterBucketsRetention(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 10*time.Minute))
+
+	for i := range 30 {
+		f.AddDataPointAt(start.Add(time.Duration(i)*time.Minute), float64(i))
+	}
+
+	history := f.History()
+	if len(history) != 11 {
+		t.Fatalf("Expected 11 buckets within retention, got %d", len(history))
+	}
+	if history[0].Value != 19 {
+		t.Errorf("Expected the oldest kept bucket to be minute 19, got %.0f", history[0].Value)
+	}
+
+	//data older than the retention window is ignored
+	f.AddDataPointAt(start, 100)
+	if f.Len() != 11 {
+		t.Errorf("Expected stale data to be dropped, got %d buckets", f.Len())
+	}
+}
+
+func TestForecasterBackfill(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, time.Hour))
+
+	f.AddDataPointAt(start.Add(2*time.Minute), 3)
+	f.AddDataPointAt(start, 1)
+	f.AddDataPointAt(start.Add(time.Minute), 2)
+	f.AddDataPointAt(start.Add(30*time.Second), 1)
+
+	history := f.History()
+	want := []float64{2, 2, 3}
+	if len(history) != len(want) {
+		t.Fatalf("Expected %d buckets, got %v", len(want), history)
+	}
+	for i, point := range history {
+		if point.Value != want[i] {
+			t.Errorf("Bucket %d: expected %.0f, got %.0f", i, want[i], point.Value)
+		}
+		if i > 0 && !point.Timestamp.After(history[i-1].Timestamp) {
+			t.Errorf("Expected buckets in time order, got %v", history)
+		}
+	}
+}
+
+func TestForecasterAddDataPointAtOrders(t *testing.T) {
+	start := time.Now()
+	f := predict.NewForecaster()
+
+	f.AddDataPointAt(start.Add(2*time.Second), 3)
+	f.AddDataPointAt(start, 1)
+	f.AddDataPointAt(start.Add(time.Second), 2)
+
+	for i, point := range f.History() {
+		if point.Value != float64(i+1) {
+			t.Errorf("Point %d: expected %d, got %.0f", i, i+1, point.Value)
+		}
+	}
+}
+
+func TestForecasterBucketsFillIdleIntervals(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minute int) time.Time { return start.Add(time.Duration(minute) * time.Minute) }
+
+	sum := predict.NewForecaster(predict.WithBuckets(time.Minute, 0))
+	mean := predict.NewForecaster(predict.WithBuckets(time.Minute, 0), predict.WithAggregation(predict.AggregateMean))
+	for _, minute := range []int{0, 1, 5, 6} {
+		sum.AddDataPointAt(at(minute), 4)
+		mean.AddDataPointAt(at(minute), 4)
+	}
+
+	history := sum.History()
+	want := []float64{4, 4, 0, 0, 0, 4, 4}
+	if len(history) != len(want) {
+		t.Fatalf("Expected %d buckets including idle minutes, got %+v", len(want), history)
+	}
+	for i, point := range history {
+		if !point.Timestamp.Equal(at(i)) || point.Value != want[i] {
+			t.Errorf("Bucket %d: expected %.0f at %v, got %+v", i, want[i], at(i), point)
+		}
+	}
+
+	if n := mean.Len(); n != 4 {
+		t.Errorf("Expected averaged buckets to skip idle minutes, got %d buckets", n)
+	}
+}
+
+func TestForecasterBucketsFillBoundedByRetention(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 10*time.Minute))
+
+	f.AddDataPointAt(start, 1)
+	f.AddDataPointAt(start.Add(7*24*time.Hour), 1)
+
+	history := f.History()
+	if len(history) != 11 {
+		t.Fatalf("Expected the retention window of zero buckets plus the newest, got %d", len(history))
+	}
+	if history[0].Value != 0 || history[10].Value != 1 {
+		t.Errorf("Expected idle buckets up to the newest, got %+v", history)
+	}
+}
+
+func TestForecasterBucketsForecastCompletedOnly(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 0))
+
+	//steady traffic of 60 a minute, then the first request of a new minute
+	for minute := range 20 {
+		for second := range 60 {
+			f.AddDataPointAt(start.Add(time.Duration(minute)*time.Minute+time.Duration(second)*time.Second), 1)
+		}
+	}
+	f.AddDataPointAt(start.Add(20*time.Minute), 1)
+
+	if got := f.PredictNext(); got != 60 {
+		t.Errorf("Expected PredictNext to ignore the filling bucket, got %.2f", got)
+	}
+	if got := f.PredictMovingAverage(); got != 60 {
+		t.Errorf("Expected PredictMovingAverage to ignore the filling bucket, got %.2f", got)
+	}
+	for h, got := range f.PredictAhead(3) {
+		if math.Abs(got-60) > 1e-9 {
+			t.Errorf("Step %d: expected 60, got %.2f", h+1, got)
+		}
+	}
+	if interval := f.PredictInterval(0.95); interval.Value != 60 || interval.Upper-interval.Lower != 0 {
+		t.Errorf("Expected a flat interval at 60, got %+v", interval)
+	}
+}
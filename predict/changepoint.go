@@ -0,0 +1,148 @@
+package predict
+
+import (
+	"math"
+	"time"
+)
+
+// ChangePoint is a sustained shift in level, e.g. after a deploy or a traffic migration
+type ChangePoint struct {
+	//the first point of the new regime
+	Timestamp time.Time
+
+	//mean level before and since the shift
+	Before float64
+	After  float64
+}
+
+// points used to learn the level and spread of each regime before shifts are looked for
+const minChangeHistory = 10
+
+// number of past change points kept for ChangePoints
+const maxChangePoints = 100
+
+// cusum is a two-sided tabular CUSUM over values standardised against a reference regime
+type cusum struct {
+	count int
+	mean  float64
+	m2    float64
+
+	//the statistics and the points since each last returned to zero
+	high, low       float64
+	highRun, lowRun []DataPoint
+}
+
+// WithChangeDetection runs CUSUM over each completed point. A shift is flagged once the
+// cumulative deviation, in standard deviations of the current regime less drift per point,
+// passes threshold (5 and 0.5 are typical). With truncate, history from before the shift
+// is dropped so forecasts follow the new level straight away.
+func WithChangeDetection(threshold, drift float64, truncate bool) Option {
+	return func(f *Forecaster) {
+		f.changeDetection = true
+		f.changeThreshold = threshold
+		f.changeDrift = drift
+		f.truncateOnChange = truncate
+
+		if f.changeThreshold <= 0 {
+			f.changeThreshold = 5
+		}
+		if f.changeDrift <= 0 {
+			f.changeDrift = 0.5
+		}
+	}
+}
+
+// WithChangeHandler is called, outside the forecaster's lock, for every detected change point
+func WithChangeHandler(handler func(ChangePoint)) Option {
+	return func(f *Forecaster) {
+		f.onChange = handler
+	}
+}
+
+// ChangePoints returns the most recent detected shifts, oldest first
+func (f *Forecaster) ChangePoints() []ChangePoint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	changes := make([]ChangePoint, len(f.changes))
+	copy(changes, f.changes)
+	return changes
+}
+
+func (f *Forecaster) trackChange(point DataPoint) {
+	c := &f.change
+	x := point.Value
+
+	//learn the regime with Welford's algorithm, then hold it as the reference
+	if c.count < minChangeHistory {
+		c.count++
+		delta := x - c.mean
+		c.mean += delta / float64(c.count)
+		c.m2 += delta * (x - c.mean)
+		return
+	}
+
+	sigma := math.Sqrt(c.m2 / float64(c.count-1))
+	if sigma == 0 {
+		sigma = math.Max(math.Abs(c.mean)*1e-3, 1e-9)
+	}
+	z := (x - c.mean) / sigma
+
+	c.high = math.Max(0, c.high+z-f.changeDrift)
+	c.highRun = extendRun(c.highRun, c.high, point)
+
+	c.low = math.Max(0, c.low-z-f.changeDrift)
+	c.lowRun = extendRun(c.lowRun, c.low, point)
+
+	switch {
+	case c.high > f.changeThreshold:
+		f.changed(shift(c.highRun, c.mean))
+	case c.low > f.changeThreshold:
+		f.changed(shift(c.lowRun, c.mean))
+	}
+}
+
+func extendRun(run []DataPoint, statistic float64, point DataPoint) []DataPoint {
+	if statistic == 0 {
+		return run[:0]
+	}
+	return append(run, point)
+}
+
+// shift places the change within run where the points after it differ most from the reference,
+// since noise often starts the run a little before the real shift
+func shift(run []DataPoint, reference float64) ChangePoint {
+	best, bestScore, bestMean := 0, -1.0, reference
+
+	sum := 0.0
+	for i := len(run) - 1; i >= 0; i-- {
+		sum += run[i].Value
+		n := float64(len(run) - i)
+		mean := sum / n
+
+		if score := n * (mean - reference) * (mean - reference); score >= bestScore {
+			best, bestScore, bestMean = i, score, mean
+		}
+	}
+
+	return ChangePoint{Timestamp: run[best].Timestamp, Before: reference, After: bestMean}
+}
+
+func (f *Forecaster) changed(change ChangePoint) {
+	f.changes = append(f.changes, change)
+	if len(f.changes) > maxChangePoints {
+		f.changes = f.changes[len(f.changes)-maxChangePoints:]
+	}
+
+	if f.onChange != nil {
+		f.pendingChanges = append(f.pendingChanges, change)
+	}
+
+	if f.truncateOnChange {
+		f.truncateBefore = change.Timestamp
+		f.errors = nil
+	}
+
+	//the new regime becomes the reference once enough of it has been seen
+	f.change = cusum{}
+}
@@ -0,0 +1,74 @@
+package predict_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+func TestChangeDetectionLevelShift(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+
+	var changes []predict.ChangePoint
+	f := predict.NewForecaster(
+		predict.WithChangeDetection(5, 0.5, true),
+		predict.WithChangeHandler(func(c predict.ChangePoint) {
+			changes = append(changes, c)
+		}))
+
+	for i := range 40 {
+		f.AddDataPointAt(at(i), steady(i))
+	}
+	if len(changes) != 0 {
+		t.Fatalf("Expected no change in steady traffic, got %+v", changes)
+	}
+
+	for i := 40; i < 45; i++ {
+		f.AddDataPointAt(at(i), 2*steady(i))
+	}
+
+	if len(changes) != 1 {
+		t.Fatalf("Expected one change point, got %+v", changes)
+	}
+	change := changes[0]
+	if !change.Timestamp.Equal(at(40)) {
+		t.Errorf("Expected the shift to start at %v, got %v", at(40), change.Timestamp)
+	}
+	if math.Abs(change.Before-100) > 2 || math.Abs(change.After-200) > 5 {
+		t.Errorf("Expected a shift from about 100 to 200, got %+v", change)
+	}
+
+	history := f.History()
+	if !history[0].Timestamp.Equal(at(40)) {
+		t.Errorf("Expected history before the shift to be dropped, starts at %v", history[0].Timestamp)
+	}
+	if got := f.PredictNext(); math.Abs(got-200) > 5 {
+		t.Errorf("Expected forecasts to follow the new level, got %.2f", got)
+	}
+
+	if got := f.ChangePoints(); len(got) != 1 || got[0] != change {
+		t.Errorf("Expected ChangePoints to record the shift, got %+v", got)
+	}
+}
+
+func TestChangeDetectionDrop(t *testing.T) {
+	f := predict.NewForecaster(predict.WithChangeDetection(0, 0, false))
+
+	for i := range 30 {
+		f.AddDataPoint(steady(i))
+	}
+	for i := range 10 {
+		f.AddDataPoint(steady(i) / 4)
+	}
+
+	changes := f.ChangePoints()
+	if len(changes) == 0 || changes[0].After >= changes[0].Before {
+		t.Fatalf("Expected a downward shift, got %+v", changes)
+	}
+	if f.Len() != 40 {
+		t.Errorf("Expected history to be kept without truncation, got %d points", f.Len())
+	}
+}
@@ -6,8 +6,8 @@ import (
 )
 
 type DataPoint struct {
-	Timestamp time.Time
-	Value     float64
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
 }
 
 type Forecaster struct {
@@ -19,14 +19,84 @@ type Forecaster struct {
 
 	alpha float64
 
+	beta float64
+
+	gamma float64
+
+	seasonLength int
+
+	seasonality Seasonality
+
+	//season found by refreshSeason, and points added since it ran
+	detectedSeason int
+	sinceSeason    int
+
+	bucketWidth time.Duration
+
+	retention time.Duration
+
+	aggregation Aggregation
+
+	//per-bucket totals, aligned with history when bucketing
+	buckets []bucket
+
+	errorWindow int
+
+	//errors of recent one-step forecasts, oldest first
+	errors []forecastError
+
+	detector Detector
+
+	anomalyThreshold float64
+
+	anomalyWindow int
+
+	onAnomaly func(Anomaly)
+
+	changeDetection bool
+
+	changeThreshold float64
+
+	changeDrift float64
+
+	truncateOnChange bool
+
+	change cusum
+
+	changes []ChangePoint
+
+	onChange func(ChangePoint)
+
+	//history before this is dropped on the next trim
+	truncateBefore time.Time
+
+	pendingAnomalies []Anomaly
+	pendingChanges   []ChangePoint
+
+	//set while Import merges points, which are not scored
+	importing bool
+
+	autoSelect     bool
+	holdout        int
+	selectEvery    int
+	sinceSelection int
+	selection      Selection
+	selected       bool
+
 	mu sync.RWMutex
 }
 
 func NewForecaster(options ...Option) *Forecaster {
 	f := &Forecaster{
-		maxHistory: 1000,
-		maWindow:   10,
-		alpha:      0.3,
+		maxHistory:  1000,
+		maWindow:    10,
+		alpha:       0.3,
+		beta:        0.1,
+		gamma:       0.1,
+		errorWindow: 50,
+
+		anomalyThreshold: 3,
+		anomalyWindow:    30,
 	}
 
 	for _, option := range options {
@@ -62,39 +132,81 @@ func WithAlpha(alpha float64) Option {
 	}
 }
 
-func (f *Forecaster) AddDataPoint(value float64) {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+// WithBeta sets the trend smoothing factor used by Holt and Holt-Winters
+func WithBeta(beta float64) Option {
+	return func(f *Forecaster) {
+		if beta >= 0 && beta <= 1 {
+			f.beta = beta
+		}
+	}
+}
 
-	f.history = append(f.history, DataPoint{
-		Timestamp: time.Now(),
-		Value:     value,
-	})
+// WithGamma sets the seasonal smoothing factor used by Holt-Winters
+func WithGamma(gamma float64) Option {
+	return func(f *Forecaster) {
+		if gamma >= 0 && gamma <= 1 {
+			f.gamma = gamma
+		}
+	}
+}
 
-	if len(f.history) > f.maxHistory {
-		f.history = f.history[len(f.history)-f.maxHistory:]
+// WithSeasonLength fixes the season in data points; without it the season comes from DetectPattern
+func WithSeasonLength(points int) Option {
+	return func(f *Forecaster) {
+		if points > 1 {
+			f.seasonLength = points
+		}
+	}
+}
+
+func WithSeasonality(seasonality Seasonality) Option {
+	return func(f *Forecaster) {
+		f.seasonality = seasonality
 	}
 }
 
+func (f *Forecaster) AddDataPoint(value float64) {
+	f.AddDataPointAt(time.Now(), value)
+}
+
+func (f *Forecaster) Len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.history)
+}
+
 func (f *Forecaster) PredictNext() float64 {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	if len(f.history) < 2 {
-		if len(f.history) == 1 {
-			return f.history[0].Value
-		}
+	return f.nextForecast(f.completed())
+}
+
+// nextForecast is PredictNext's one-step forecast from history: the selected model once
+// WithAutoSelect has chosen one, exponential smoothing otherwise
+func (f *Forecaster) nextForecast(history []DataPoint) float64 {
+	switch {
+	case len(history) == 0:
 		return 0
+	case len(history) == 1:
+		return history[0].Value
+	case f.selected:
+		values := make([]float64, len(history))
+		for i, point := range history {
+			values[i] = point.Value
+		}
+		return f.selection.forecast(values, f.seasonality, 1)[0]
+	default:
+		return smooth(history, f.maWindow, f.alpha)
 	}
-
-	return f.predictExponentialSmoothing()
 }
 
 func (f *Forecaster) PredictMovingAverage() float64 {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	histLen := len(f.history)
+	history := f.completed()
+	histLen := len(history)
 	if histLen == 0 {
 		return 0
 	}
@@ -102,23 +214,24 @@ func (f *Forecaster) PredictMovingAverage() float64 {
 	window := min(f.maWindow, histLen)
 	sum := 0.0
 	for i := histLen - window; i < histLen; i++ {
-		sum += f.history[i].Value
+		sum += history[i].Value
 	}
 
 	return sum / float64(window)
 }
 
-func (f *Forecaster) predictExponentialSmoothing() float64 {
-	histLen := len(f.history)
+// smooth exponentially smooths the last window points of history
+func smooth(history []DataPoint, window int, alpha float64) float64 {
+	histLen := len(history)
 	if histLen == 0 {
 		return 0
 	}
 
-	startIdx := max(0, histLen-f.maWindow)
-	forecast := f.history[startIdx].Value
+	startIdx := max(0, histLen-window)
+	forecast := history[startIdx].Value
 
 	for i := startIdx + 1; i < histLen; i++ {
-		forecast = f.alpha*f.history[i].Value + (1-f.alpha)*forecast
+		forecast = alpha*history[i].Value + (1-alpha)*forecast
 	}
 
 	return forecast
@@ -128,97 +241,24 @@ func (f *Forecaster) DetectPattern() (period time.Duration, confidence float64)
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	if len(f.history) < 24 {
-		return 0, 0
-	}
-
-	patterns := []time.Duration{
-		time.Hour,
-		24 * time.Hour,
-		7 * 24 * time.Hour,
-	}
-
-	bestConfidence := 0.0
-	bestPeriod := time.Duration(0)
-
-	for _, testPeriod := range patterns {
-		confidence := f.evaluatePattern(testPeriod)
-		if confidence > bestConfidence {
-			bestConfidence = confidence
-			bestPeriod = testPeriod
-		}
-	}
-
-	return bestPeriod, bestConfidence
+	return f.detectPattern()
 }
 
-func (f *Forecaster) evaluatePattern(period time.Duration) float64 {
-	bucketCount := 24 // Divide the period into 24 buckets
-	buckets := make([][]float64, bucketCount)
-
-	now := time.Now()
-
-	for _, point := range f.history {
-		age := now.Sub(point.Timestamp)
-		position := int((age.Nanoseconds() % period.Nanoseconds()) * int64(bucketCount) / period.Nanoseconds())
-		position = min(position, bucketCount-1)
-
-		buckets[position] = append(buckets[position], point.Value)
-	}
-
-	totalVariance := f.calculateVariance(f.extractValues())
-	if totalVariance == 0 {
-		return 0 // No variation in the data
-	}
-
-	var withinBucketVariance float64
-	var totalWeight float64
-
-	for _, bucket := range buckets {
-		if len(bucket) > 1 {
-			variance := f.calculateVariance(bucket)
-			weight := float64(len(bucket))
-			withinBucketVariance += variance * weight
-			totalWeight += weight
-		}
-	}
-
-	if totalWeight == 0 {
-		return 0
-	}
-
-	withinBucketVariance /= totalWeight
-
-	explainedVariance := 1.0 - (withinBucketVariance / totalVariance)
-
-	dataConfidence := minFloat64(1.0, float64(len(f.history))/100.0)
-
-	return explainedVariance * dataConfidence
-}
-
-func (f *Forecaster) calculateVariance(values []float64) float64 {
-	if len(values) < 2 {
-		return 0
-	}
-
-	sum := 0.0
-	for _, v := range values {
-		sum += v
-	}
-	mean := sum / float64(len(values))
-
-	varSum := 0.0
-	for _, v := range values {
-		diff := v - mean
-		varSum += diff * diff
+// detectPattern returns the strongest autocorrelation period, or zero when none is found
+func (f *Forecaster) detectPattern() (period time.Duration, confidence float64) {
+	periods := f.detectPeriods(1)
+	if len(periods) == 0 {
+		return 0, 0
 	}
 
-	return varSum / float64(len(values))
+	return periods[0].Duration, periods[0].Confidence
 }
 
+// extractValues returns the values of the completed history
 func (f *Forecaster) extractValues() []float64 {
-	values := make([]float64, len(f.history))
-	for i, point := range f.history {
+	history := f.completed()
+	values := make([]float64, len(history))
+	for i, point := range history {
 		values[i] = point.Value
 	}
 	return values
@@ -237,10 +277,3 @@ func max(a, b int) int {
 	}
 	return b
 }
-
-func minFloat64(a, b float64) float64 {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,162 @@
+package predict
+
+type Seasonality int
+
+const (
+	//the seasonal swing is a fixed amount added to the level
+	SeasonalityAdditive Seasonality = iota
+
+	//the seasonal swing scales with the level
+	SeasonalityMultiplicative
+)
+
+// minimum DetectPattern confidence before its period is used as the season
+const minSeasonConfidence = 0.3
+
+// PredictAhead forecasts the next n points. It uses Holt-Winters once two full seasons of
+// history are available, Holt's linear trend with less, and repeats the last value with one point.
+// With WithAutoSelect it uses the selected model instead once one has been chosen.
+func (f *Forecaster) PredictAhead(n int) []float64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if n <= 0 {
+		return nil
+	}
+
+	values := f.extractValues()
+	switch {
+	case len(values) == 0:
+		return make([]float64, n)
+	case len(values) == 1:
+		forecast := make([]float64, n)
+		for i := range forecast {
+			forecast[i] = values[0]
+		}
+		return forecast
+	}
+
+	if f.selected {
+		return f.selection.forecast(values, f.seasonality, n)
+	}
+
+	season := f.season()
+	if season > 1 && len(values) >= 2*season {
+		return holtWinters(values, season, f.alpha, f.beta, f.gamma, f.seasonality, n)
+	}
+
+	return holt(values, f.alpha, f.beta, n)
+}
+
+// points added between searches for the season when none is configured, or a tenth of the
+// history once that is more
+const seasonRefreshEvery = 60
+
+// season returns the configured season length, or the strongest period found by the last
+// refreshSeason, in raw points
+func (f *Forecaster) season() int {
+	if f.seasonLength > 0 {
+		return f.seasonLength
+	}
+	return f.detectedSeason
+}
+
+// refreshSeason re-runs period detection, which is quadratic in the history, so it is done
+// every so many points added rather than for every forecast or scored point
+func (f *Forecaster) refreshSeason() {
+	f.sinceSeason = 0
+	if f.seasonLength > 0 {
+		return
+	}
+
+	f.detectedSeason = 0
+	if periods := f.detectPeriods(1); len(periods) > 0 && periods[0].Confidence >= minSeasonConfidence {
+		f.detectedSeason = periods[0].Points
+	}
+}
+
+// holt is double exponential smoothing: a level and a linear trend
+func holt(values []float64, alpha, beta float64, n int) []float64 {
+	level := values[0]
+	trend := values[1] - values[0]
+
+	for _, value := range values[1:] {
+		lastLevel := level
+		level = alpha*value + (1-alpha)*(level+trend)
+		trend = beta*(level-lastLevel) + (1-beta)*trend
+	}
+
+	forecast := make([]float64, n)
+	for h := range forecast {
+		forecast[h] = level + float64(h+1)*trend
+	}
+	return forecast
+}
+
+// holtWinters is triple exponential smoothing; values must hold at least two seasons
+func holtWinters(values []float64, season int, alpha, beta, gamma float64, seasonality Seasonality, n int) []float64 {
+	firstMean := mean(values[:season])
+	secondMean := mean(values[season : 2*season])
+
+	level := firstMean
+	trend := (secondMean - firstMean) / float64(season)
+
+	seasonals := make([]float64, season)
+	for i := range seasonals {
+		if seasonality == SeasonalityMultiplicative {
+			seasonals[i] = safeRatio(values[i], level)
+		} else {
+			seasonals[i] = values[i] - level
+		}
+	}
+
+	for t := season; t < len(values); t++ {
+		value := values[t]
+		i := t % season
+		lastLevel := level
+
+		if seasonality == SeasonalityMultiplicative {
+			level = alpha*safeRatio(value, seasonals[i]) + (1-alpha)*(level+trend)
+			trend = beta*(level-lastLevel) + (1-beta)*trend
+			seasonals[i] = gamma*safeRatio(value, level) + (1-gamma)*seasonals[i]
+		} else {
+			level = alpha*(value-seasonals[i]) + (1-alpha)*(level+trend)
+			trend = beta*(level-lastLevel) + (1-beta)*trend
+			seasonals[i] = gamma*(value-level) + (1-gamma)*seasonals[i]
+		}
+	}
+
+	last := len(values) - 1
+	forecast := make([]float64, n)
+	for h := range forecast {
+		base := level + float64(h+1)*trend
+		seasonal := seasonals[(last+h+1)%season]
+
+		if seasonality == SeasonalityMultiplicative {
+			forecast[h] = base * seasonal
+		} else {
+			forecast[h] = base + seasonal
+		}
+	}
+	return forecast
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// safeRatio divides, treating a zero denominator as no seasonal effect
+func safeRatio(a, b float64) float64 {
+	if b == 0 {
+		return 1
+	}
+	return a / b
+}
@@ -0,0 +1,70 @@
+package predict_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+func TestHoltWintersAdditive(t *testing.T) {
+	pattern := []float64{3, -1, -3, 1}
+	value := func(i int) float64 { return 10 + 0.5*float64(i) + pattern[i%4] }
+
+	f := predict.NewForecaster(predict.WithSeasonLength(4), predict.WithAlpha(0.5), predict.WithBeta(0.2), predict.WithGamma(0.3))
+	for i := range 40 {
+		f.AddDataPoint(value(i))
+	}
+
+	forecast := f.PredictAhead(6)
+	if len(forecast) != 6 {
+		t.Fatalf("Expected 6 forecast points, got %d", len(forecast))
+	}
+
+	for h, got := range forecast {
+		want := value(40 + h)
+		if math.Abs(got-want) > 0.5 {
+			t.Errorf("Step %d: expected about %.2f, got %.2f", h+1, want, got)
+		}
+	}
+}
+
+func TestHoltWintersMultiplicative(t *testing.T) {
+	pattern := []float64{1.2, 0.9, 0.8, 1.1}
+	value := func(i int) float64 { return (20 + float64(i)) * pattern[i%4] }
+
+	f := predict.NewForecaster(
+		predict.WithSeasonLength(4),
+		predict.WithSeasonality(predict.SeasonalityMultiplicative),
+		predict.WithAlpha(0.5), predict.WithBeta(0.2), predict.WithGamma(0.3))
+	for i := range 48 {
+		f.AddDataPoint(value(i))
+	}
+
+	for h, got := range f.PredictAhead(4) {
+		want := value(48 + h)
+		if math.Abs(got-want)/want > 0.05 {
+			t.Errorf("Step %d: expected about %.2f, got %.2f", h+1, want, got)
+		}
+	}
+}
+
+func TestPredictAheadTrendFallback(t *testing.T) {
+	f := predict.NewForecaster()
+	for i := range 10 {
+		f.AddDataPoint(float64(10 * i))
+	}
+
+	forecast := f.PredictAhead(3)
+	for h, got := range forecast {
+		want := float64(10 * (10 + h))
+		if math.Abs(got-want) > 1 {
+			t.Errorf("Without a season the trend should continue: step %d expected %.0f, got %.2f", h+1, want, got)
+		}
+	}
+
+	empty := predict.NewForecaster()
+	if got := empty.PredictAhead(2); len(got) != 2 || got[0] != 0 {
+		t.Errorf("Empty history should forecast zeros, got %v", got)
+	}
+}
@@ -0,0 +1,173 @@
+package predict
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Period is a candidate seasonal cycle found by autocorrelation
+type Period struct {
+	Duration time.Duration
+
+	//the cycle length in evenly spaced points at the median sampling interval
+	Lag int
+
+	//the cycle length in raw history points, Duration over the mean sampling interval;
+	//this is the season for models that run on the history itself
+	Points int
+
+	//the autocorrelation at Lag (0.0-1.0)
+	Confidence float64
+}
+
+// minimum number of points before periods are searched for
+const minPeriodHistory = 24
+
+// maximum number of points produced when resampling, relative to the raw history
+const maxResampleFactor = 4
+
+// DetectPeriods returns up to maxCandidates periods, strongest first. The history is resampled
+// onto an evenly spaced grid and detrended, so irregular sampling and growth do not hide cycles.
+func (f *Forecaster) DetectPeriods(maxCandidates int) []Period {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.detectPeriods(maxCandidates)
+}
+
+func (f *Forecaster) detectPeriods(maxCandidates int) []Period {
+	history := f.completed()
+	if len(history) < minPeriodHistory || maxCandidates <= 0 {
+		return nil
+	}
+
+	values, step := resample(history)
+	if len(values) < minPeriodHistory {
+		return nil
+	}
+
+	acf := autocorrelation(detrend(values), len(values)/2)
+
+	span := history[len(history)-1].Timestamp.Sub(history[0].Timestamp)
+	meanStep := float64(span) / float64(len(history)-1)
+
+	var candidates []Period
+	for lag := 2; lag < len(acf)-1; lag++ {
+		if acf[lag] > 0 && acf[lag] > acf[lag-1] && acf[lag] >= acf[lag+1] {
+			period := Period{
+				Duration:   time.Duration(lag) * step,
+				Lag:        lag,
+				Points:     lag,
+				Confidence: math.Min(acf[lag], 1),
+			}
+			if meanStep > 0 {
+				period.Points = int(math.Round(float64(period.Duration) / meanStep))
+			}
+			candidates = append(candidates, period)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+	return candidates
+}
+
+// resample linearly interpolates points onto a grid spaced at their median interval, or wider
+// when that would take more than maxResampleFactor times as many points to cover the span
+func resample(points []DataPoint) ([]float64, time.Duration) {
+	step := medianStep(points)
+	span := points[len(points)-1].Timestamp.Sub(points[0].Timestamp)
+	if step <= 0 || span <= 0 {
+		values := make([]float64, len(points))
+		for i, point := range points {
+			values[i] = point.Value
+		}
+		return values, step
+	}
+
+	//a grid too fine for the span is widened so it still reaches the newest point
+	n := int(span/step) + 1
+	if limit := maxResampleFactor * len(points); n > limit {
+		n = limit
+		step = span / time.Duration(n-1)
+	}
+
+	values := make([]float64, n)
+	j := 0
+	for i := range values {
+		at := points[0].Timestamp.Add(time.Duration(i) * step)
+		for j < len(points)-2 && points[j+1].Timestamp.Before(at) {
+			j++
+		}
+
+		a, b := points[j], points[j+1]
+		gap := b.Timestamp.Sub(a.Timestamp)
+		if gap <= 0 {
+			values[i] = b.Value
+			continue
+		}
+
+		weight := float64(at.Sub(a.Timestamp)) / float64(gap)
+		weight = math.Max(0, math.Min(weight, 1))
+		values[i] = a.Value + weight*(b.Value-a.Value)
+	}
+
+	return values, step
+}
+
+func medianStep(points []DataPoint) time.Duration {
+	if len(points) < 2 {
+		return 0
+	}
+
+	steps := make([]time.Duration, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		steps = append(steps, points[i].Timestamp.Sub(points[i-1].Timestamp))
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i] < steps[j] })
+
+	return steps[len(steps)/2]
+}
+
+// detrend removes the least-squares line through values
+func detrend(values []float64) []float64 {
+	xs := make([]float64, len(values))
+	for i := range xs {
+		xs[i] = float64(i)
+	}
+	slope, intercept := leastSquares(xs, values)
+
+	residuals := make([]float64, len(values))
+	for i, v := range values {
+		residuals[i] = v - (intercept + slope*xs[i])
+	}
+	return residuals
+}
+
+// autocorrelation returns the sample autocorrelation of zero-mean values for lags 0..maxLag
+func autocorrelation(values []float64, maxLag int) []float64 {
+	energy := 0.0
+	for _, v := range values {
+		energy += v * v
+	}
+
+	acf := make([]float64, maxLag+1)
+	if energy == 0 {
+		return acf
+	}
+
+	for lag := range acf {
+		sum := 0.0
+		for t := 0; t+lag < len(values); t++ {
+			sum += values[t] * values[t+lag]
+		}
+		acf[lag] = sum / energy
+	}
+	return acf
+}
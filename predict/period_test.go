@@ -0,0 +1,134 @@
+package predict
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestDetectPeriodsIrregularSampling(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	start := time.Unix(0, 0)
+
+	//a 12 minute cycle on a rising trend, sampled roughly every minute with jitter
+	f := NewForecaster()
+	at := start
+	for range 120 {
+		minutes := at.Sub(start).Minutes()
+		f.history = append(f.history, DataPoint{
+			Timestamp: at,
+			Value:     50 + 0.5*minutes + 10*math.Sin(2*math.Pi*minutes/12),
+		})
+		at = at.Add(time.Minute + time.Duration(rng.Intn(20)-10)*time.Second)
+	}
+
+	periods := f.DetectPeriods(3)
+	if len(periods) == 0 {
+		t.Fatal("Expected at least one period")
+	}
+
+	top := periods[0]
+	if top.Duration < 11*time.Minute || top.Duration > 13*time.Minute {
+		t.Errorf("Expected a period of about 12m, got %v", top.Duration)
+	}
+	if top.Confidence < 0.5 {
+		t.Errorf("Expected a confident period, got %.2f", top.Confidence)
+	}
+
+	for i := 1; i < len(periods); i++ {
+		if periods[i].Confidence > periods[i-1].Confidence {
+			t.Errorf("Expected candidates strongest first, got %v", periods)
+		}
+	}
+
+	period, confidence := f.DetectPattern()
+	if period != top.Duration || confidence != top.Confidence {
+		t.Errorf("Expected DetectPattern to report the top candidate, got %v (%.2f)", period, confidence)
+	}
+}
+
+func TestDetectPeriodsNoise(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	start := time.Unix(0, 0)
+
+	f := NewForecaster()
+	for i := range 200 {
+		f.history = append(f.history, DataPoint{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Value:     rng.Float64(),
+		})
+	}
+
+	for _, period := range f.DetectPeriods(5) {
+		if period.Confidence >= minSeasonConfidence {
+			t.Errorf("Expected no confident period in noise, got %v", period)
+		}
+	}
+}
+
+func TestDetectPeriodsShortHistory(t *testing.T) {
+	f := NewForecaster()
+	for i := range minPeriodHistory - 1 {
+		f.AddDataPoint(float64(i % 4))
+	}
+
+	if periods := f.DetectPeriods(1); periods != nil {
+		t.Errorf("Expected no periods from short history, got %v", periods)
+	}
+}
+
+func TestDetectPeriodsPointsUnderIrregularSampling(t *testing.T) {
+	start := time.Unix(0, 0)
+
+	//a 12 point cycle sampled at alternating 1m and 3m steps, so 24m long
+	f := NewForecaster()
+	at := start
+	for i := range 96 {
+		f.history = append(f.history, DataPoint{Timestamp: at, Value: 50 + 10*math.Sin(2*math.Pi*float64(i)/12)})
+		if i%2 == 0 {
+			at = at.Add(time.Minute)
+		} else {
+			at = at.Add(3 * time.Minute)
+		}
+	}
+
+	periods := f.DetectPeriods(1)
+	if len(periods) == 0 {
+		t.Fatal("Expected a period")
+	}
+	if periods[0].Duration != 24*time.Minute {
+		t.Errorf("Expected a 24m period, got %v", periods[0].Duration)
+	}
+	if periods[0].Points != 12 {
+		t.Errorf("Expected a season of 12 raw points, got %d (lag %d)", periods[0].Points, periods[0].Lag)
+	}
+	f.refreshSeason()
+	if season := f.season(); season != 12 {
+		t.Errorf("Expected the seasonal models to use 12 points, got %d", season)
+	}
+}
+
+func TestDetectPeriodsMixedSamplingRates(t *testing.T) {
+	start := time.Unix(0, 0)
+
+	//a burst of second-level samples followed by hourly samples of a 12h cycle
+	f := NewForecaster()
+	at := start
+	for range 60 {
+		f.history = append(f.history, DataPoint{Timestamp: at, Value: 50})
+		at = at.Add(time.Second)
+	}
+	for i := range 50 {
+		f.history = append(f.history, DataPoint{Timestamp: at, Value: 50 + 10*math.Sin(2*math.Pi*float64(i)/12)})
+		at = at.Add(time.Hour)
+	}
+
+	periods := f.DetectPeriods(3)
+	if len(periods) == 0 {
+		t.Fatal("Expected the hourly samples to still be searched")
+	}
+	if d := periods[0].Duration; d < 11*time.Hour || d > 13*time.Hour {
+		t.Errorf("Expected a period of about 12h, got %v", d)
+	}
+}
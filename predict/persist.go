@@ -0,0 +1,259 @@
+package predict
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Format is the encoding used by Export, Import and Checkpointer
+type Format int
+
+const (
+	//FormatJSON is an array of {"timestamp": RFC 3339, "value": number} objects
+	FormatJSON Format = iota
+
+	//FormatCSV has a timestamp,value header followed by one RFC 3339 timestamp and value per row
+	FormatCSV
+)
+
+var csvHeader = []string{"timestamp", "value"}
+
+// Export writes the history, or the buckets when bucketing, oldest first
+func (f *Forecaster) Export(w io.Writer, format Format) error {
+	history := f.History()
+
+	switch format {
+	case FormatJSON:
+		if err := json.NewEncoder(w).Encode(history); err != nil {
+			return fmt.Errorf("predict: export json: %w", err)
+		}
+		return nil
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return fmt.Errorf("predict: export csv: %w", err)
+		}
+		for _, point := range history {
+			record := []string{
+				point.Timestamp.Format(time.RFC3339Nano),
+				strconv.FormatFloat(point.Value, 'g', -1, 64),
+			}
+			if err := cw.Write(record); err != nil {
+				return fmt.Errorf("predict: export csv: %w", err)
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("predict: export csv: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("predict: unknown format %d", format)
+	}
+}
+
+// Import merges points into the history, applying bucketing and retention. Nothing is added
+// unless the whole input parses. Imported points are not scored for accuracy, anomalies or
+// change points; change detection starts afresh from the next point added.
+func (f *Forecaster) Import(r io.Reader, format Format) error {
+	var points []DataPoint
+
+	switch format {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&points); err != nil {
+			return fmt.Errorf("predict: import json: %w", err)
+		}
+	case FormatCSV:
+		var err error
+		if points, err = readCSV(r); err != nil {
+			return fmt.Errorf("predict: import csv: %w", err)
+		}
+	default:
+		return fmt.Errorf("predict: unknown format %d", format)
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.importing = true
+	for _, point := range points {
+		if f.bucketWidth > 0 {
+			f.addToBucket(point.Timestamp, point.Value)
+		} else {
+			f.insert(point.Timestamp, point.Value)
+		}
+		f.trim()
+	}
+	f.importing = false
+
+	f.change = cusum{}
+	if f.autoSelect {
+		f.selectModel()
+	} else {
+		f.refreshSeason()
+	}
+
+	return nil
+}
+
+func readCSV(r io.Reader) ([]DataPoint, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) > 0 && records[0][0] == csvHeader[0] {
+		records = records[1:]
+	}
+
+	points := make([]DataPoint, 0, len(records))
+	for i, record := range records {
+		timestamp, err := time.Parse(time.RFC3339Nano, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		value, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		points = append(points, DataPoint{Timestamp: timestamp, Value: value})
+	}
+
+	return points, nil
+}
+
+// Checkpointer periodically saves a forecaster's history to a file, so a restarted
+// process picks up the traffic shape it had learned
+type Checkpointer struct {
+	forecaster *Forecaster
+	path       string
+	format     Format
+	interval   time.Duration
+
+	mu  sync.Mutex
+	err error
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// NewCheckpointer imports the checkpoint at path, if there is one, then saves every interval;
+// with interval <= 0 it only saves on Save and Stop. When the checkpoint cannot be restored an
+// error is returned and nothing is saved, so the file is not replaced with a new, empty history.
+func NewCheckpointer(f *Forecaster, path string, interval time.Duration, format Format) (*Checkpointer, error) {
+	c := &Checkpointer{
+		forecaster: f,
+		path:       path,
+		format:     format,
+		interval:   interval,
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+
+	if err := c.restore(); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		go c.run()
+	} else {
+		close(c.doneCh)
+	}
+
+	return c, nil
+}
+
+// restore imports the last checkpoint; a missing file is not an error
+func (c *Checkpointer) restore() error {
+	file, err := os.Open(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("predict: restore checkpoint: %w", err)
+	}
+	defer file.Close()
+
+	return c.forecaster.Import(file, c.format)
+}
+
+// Save writes a checkpoint now, replacing the file atomically
+func (c *Checkpointer) Save() error {
+	err := c.save()
+
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+
+	return err
+}
+
+// Err returns the outcome of the latest save
+func (c *Checkpointer) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Stop ends periodic saving and writes a final checkpoint
+func (c *Checkpointer) Stop() error {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+	<-c.doneCh
+
+	return c.Save()
+}
+
+func (c *Checkpointer) run() {
+	defer close(c.doneCh)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.Save()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+func (c *Checkpointer) save() error {
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("predict: save checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := c.forecaster.Export(tmp, c.format); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("predict: save checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("predict: save checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package predict_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+func filledForecaster(n int, options ...predict.Option) *predict.Forecaster {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(options...)
+	for i := range n {
+		f.AddDataPointAt(start.Add(time.Duration(i)*time.Minute), float64(i))
+	}
+	return f
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for name, format := range map[string]predict.Format{"json": predict.FormatJSON, "csv": predict.FormatCSV} {
+		t.Run(name, func(t *testing.T) {
+			original := filledForecaster(50)
+
+			var buf bytes.Buffer
+			if err := original.Export(&buf, format); err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+
+			restored := predict.NewForecaster()
+			if err := restored.Import(&buf, format); err != nil {
+				t.Fatalf("Import failed: %v", err)
+			}
+
+			want, got := original.History(), restored.History()
+			if len(got) != len(want) {
+				t.Fatalf("Expected %d points, got %d", len(want), len(got))
+			}
+			for i := range want {
+				if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Value != want[i].Value {
+					t.Errorf("Point %d: expected %+v, got %+v", i, want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestImportCSVIntoBuckets(t *testing.T) {
+	input := `timestamp,value
+2024-01-01T00:01:30Z,2
+2024-01-01T00:00:10Z,1
+2024-01-01T00:00:50Z,1
+`
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 0))
+	if err := f.Import(strings.NewReader(input), predict.FormatCSV); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	history := f.History()
+	if len(history) != 2 || history[0].Value != 2 || history[1].Value != 2 {
+		t.Errorf("Expected two buckets of 2, got %+v", history)
+	}
+}
+
+func TestImportRejectsBadInput(t *testing.T) {
+	f := filledForecaster(5)
+
+	input := "timestamp,value\n2024-01-01T00:00:00Z,1\nyesterday,2\n"
+	if err := f.Import(strings.NewReader(input), predict.FormatCSV); err == nil {
+		t.Error("Expected an error for an unparseable timestamp")
+	}
+	if err := f.Import(strings.NewReader("{"), predict.FormatJSON); err == nil {
+		t.Error("Expected an error for malformed JSON")
+	}
+	if f.Len() != 5 {
+		t.Errorf("Expected a failed import to leave history alone, got %d points", f.Len())
+	}
+}
+
+func TestCheckpointer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forecast.json")
+
+	f := filledForecaster(30)
+	c, err := predict.NewCheckpointer(f, path, 10*time.Millisecond, predict.FormatJSON)
+	if err != nil {
+		t.Fatalf("NewCheckpointer failed: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := c.Err(); err != nil {
+		t.Fatalf("Periodic save failed: %v", err)
+	}
+
+	f.AddDataPoint(1000)
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Final save failed: %v", err)
+	}
+
+	restored := predict.NewForecaster()
+	restorer, err := predict.NewCheckpointer(restored, path, time.Hour, predict.FormatJSON)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	defer restorer.Stop()
+	if restored.Len() != 31 {
+		t.Errorf("Expected the final checkpoint to hold 31 points, got %d", restored.Len())
+	}
+
+	missing, err := predict.NewCheckpointer(predict.NewForecaster(), filepath.Join(t.TempDir(), "none.csv"), time.Hour, predict.FormatCSV)
+	if err != nil {
+		t.Fatalf("Expected a missing checkpoint to be ignored, got %v", err)
+	}
+	defer missing.Stop()
+}
+
+func TestCheckpointerKeepsUnreadableCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forecast.json")
+	corrupt := []byte(`[{"timestamp":`)
+	if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := predict.NewCheckpointer(predict.NewForecaster(), path, 10*time.Millisecond, predict.FormatJSON); err == nil {
+		t.Fatal("Expected an error for an unreadable checkpoint")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, corrupt) {
+		t.Errorf("Expected the checkpoint to be left for inspection, got %q (%v)", data, err)
+	}
+}
+
+func TestImportDoesNotScorePoints(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	//two days of minutes with a level shift halfway and a spike near the end
+	source := predict.NewForecaster(predict.WithMaxHistory(3000))
+	for i := range 2880 {
+		value := steady(i)
+		if i >= 1440 {
+			value *= 3
+		}
+		if i == 2800 {
+			value = 10000
+		}
+		source.AddDataPointAt(start.Add(time.Duration(i)*time.Minute), value)
+	}
+
+	var buf bytes.Buffer
+	if err := source.Export(&buf, predict.FormatCSV); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	calls := 0
+	f := predict.NewForecaster(
+		predict.WithMaxHistory(3000),
+		predict.WithChangeDetection(5, 0.5, true),
+		predict.WithChangeHandler(func(predict.ChangePoint) { calls++ }),
+		predict.WithAnomalyDetector(predict.DetectSeasonal, 3.5, 30),
+		predict.WithAnomalyHandler(func(predict.Anomaly) { calls++ }))
+
+	began := time.Now()
+	if err := f.Import(&buf, predict.FormatCSV); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("Import should not run detectors per point, took %v", elapsed)
+	}
+
+	if f.Len() != 2880 {
+		t.Errorf("Expected the imported level shift to keep all 2880 points, got %d", f.Len())
+	}
+	if calls != 0 || len(f.ChangePoints()) != 0 {
+		t.Errorf("Expected no events for imported points, got %d calls and %v", calls, f.ChangePoints())
+	}
+	if acc := f.Accuracy(); acc.Samples != 0 {
+		t.Errorf("Expected imported points not to be scored, got %+v", acc)
+	}
+}
+
+func TestCheckpointerWithoutInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forecast.csv")
+
+	c, err := predict.NewCheckpointer(filledForecaster(10), path, 0, predict.FormatCSV)
+	if err != nil {
+		t.Fatalf("NewCheckpointer failed: %v", err)
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Final save failed: %v", err)
+	}
+
+	restored := predict.NewForecaster()
+	if _, err := predict.NewCheckpointer(restored, path, 0, predict.FormatCSV); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored.Len() != 10 {
+		t.Errorf("Expected 10 restored points, got %d", restored.Len())
+	}
+}
@@ -0,0 +1,166 @@
+package predict
+
+import (
+	"math"
+)
+
+type Model int
+
+const (
+	ModelMovingAverage Model = iota
+	ModelSES
+	ModelHolt
+	ModelHoltWinters
+)
+
+func (m Model) String() string {
+	switch m {
+	case ModelMovingAverage:
+		return "moving-average"
+	case ModelSES:
+		return "ses"
+	case ModelHolt:
+		return "holt"
+	case ModelHoltWinters:
+		return "holt-winters"
+	default:
+		return "unknown"
+	}
+}
+
+// Selection is a model and its parameters, with the error it scored on the holdout
+type Selection struct {
+	Model Model
+
+	//points averaged by ModelMovingAverage
+	Window int
+
+	Alpha float64
+	Beta  float64
+	Gamma float64
+
+	//season length in points for ModelHoltWinters
+	Season int
+
+	//mean absolute error of one-step forecasts over the holdout
+	MAE float64
+}
+
+// parameter grids searched for each model
+var (
+	windowGrid = []int{3, 5, 10, 20}
+	alphaGrid  = []float64{0.1, 0.3, 0.5, 0.8}
+	betaGrid   = []float64{0.05, 0.1, 0.3}
+	gammaGrid  = []float64{0.1, 0.3}
+)
+
+// WithAutoSelect backtests moving average, SES, Holt and Holt-Winters over a grid of parameters,
+// forecasting each of the last holdout points from the points before it, and uses the model with
+// the lowest error for PredictNext and PredictAhead. The choice is revisited every points added.
+func WithAutoSelect(holdout, every int) Option {
+	return func(f *Forecaster) {
+		if holdout > 0 {
+			f.autoSelect = true
+			f.holdout = holdout
+			f.selectEvery = max(every, 1)
+		}
+	}
+}
+
+// Selected returns the model in use, reporting false until auto-selection has enough history
+func (f *Forecaster) Selected() (Selection, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.selection, f.selected
+}
+
+// SelectModel re-runs the backtest now instead of waiting for the next scheduled one
+func (f *Forecaster) SelectModel() (Selection, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.selectModel()
+	return f.selection, f.selected
+}
+
+// selectModel needs holdout points plus enough before them to seed Holt's trend
+func (f *Forecaster) selectModel() {
+	f.sinceSelection = 0
+	f.refreshSeason()
+
+	if !f.autoSelect || len(f.completed()) < f.holdout+3 {
+		return
+	}
+
+	values := f.extractValues()
+
+	best := Selection{MAE: math.Inf(1)}
+	try := func(candidate Selection) {
+		candidate.MAE = backtest(values, f.holdout, candidate, f.seasonality)
+		if candidate.MAE < best.MAE {
+			best = candidate
+		}
+	}
+
+	for _, window := range windowGrid {
+		try(Selection{Model: ModelMovingAverage, Window: window})
+	}
+
+	for _, alpha := range alphaGrid {
+		try(Selection{Model: ModelSES, Alpha: alpha})
+
+		for _, beta := range betaGrid {
+			try(Selection{Model: ModelHolt, Alpha: alpha, Beta: beta})
+		}
+	}
+
+	if season := f.season(); season > 1 && len(values)-f.holdout >= 2*season {
+		for _, alpha := range alphaGrid {
+			for _, beta := range betaGrid {
+				for _, gamma := range gammaGrid {
+					try(Selection{Model: ModelHoltWinters, Alpha: alpha, Beta: beta, Gamma: gamma, Season: season})
+				}
+			}
+		}
+	}
+
+	f.selection, f.selected = best, true
+}
+
+// backtest returns the mean absolute error of one-step forecasts of the last holdout values
+func backtest(values []float64, holdout int, selection Selection, seasonality Seasonality) float64 {
+	total := 0.0
+	for t := len(values) - holdout; t < len(values); t++ {
+		total += math.Abs(values[t] - selection.forecast(values[:t], seasonality, 1)[0])
+	}
+	return total / float64(holdout)
+}
+
+// forecast projects n points past values, which must hold at least two
+func (s Selection) forecast(values []float64, seasonality Seasonality, n int) []float64 {
+	switch s.Model {
+	case ModelMovingAverage:
+		return repeat(mean(values[max(0, len(values)-s.Window):]), n)
+	case ModelSES:
+		level := values[0]
+		for _, value := range values[1:] {
+			level = s.Alpha*value + (1-s.Alpha)*level
+		}
+		return repeat(level, n)
+	case ModelHoltWinters:
+		if len(values) >= 2*s.Season {
+			return holtWinters(values, s.Season, s.Alpha, s.Beta, s.Gamma, seasonality, n)
+		}
+		fallthrough
+	default:
+		return holt(values, s.Alpha, s.Beta, n)
+	}
+}
+
+func repeat(value float64, n int) []float64 {
+	forecast := make([]float64, n)
+	for i := range forecast {
+		forecast[i] = value
+	}
+	return forecast
+}
@@ -0,0 +1,107 @@
+package predict_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+// feed adds values a minute apart, so period detection does not depend on timing
+func feed(f *predict.Forecaster, values ...float64) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(f.Len()) * time.Minute)
+	for i, value := range values {
+		f.AddDataPointAt(start.Add(time.Duration(i)*time.Minute), value)
+	}
+}
+
+func TestAutoSelectTrend(t *testing.T) {
+	f := predict.NewForecaster(predict.WithAutoSelect(10, 5))
+	for i := range 60 {
+		feed(f, 10+3*float64(i))
+	}
+
+	selection, ok := f.Selected()
+	if !ok || selection.Model != predict.ModelHolt {
+		t.Fatalf("Expected Holt for a linear trend, got %+v (%v)", selection, ok)
+	}
+	if got := f.PredictNext(); math.Abs(got-190) > 1 {
+		t.Errorf("Expected the next value to follow the trend to 190, got %.2f", got)
+	}
+}
+
+func TestAutoSelectSeasonal(t *testing.T) {
+	pattern := []float64{3, -1, -3, 1}
+	value := func(i int) float64 { return 50 + pattern[i%4] }
+
+	f := predict.NewForecaster(predict.WithSeasonLength(4), predict.WithAutoSelect(12, 4))
+	for i := range 48 {
+		feed(f, value(i))
+	}
+
+	selection, ok := f.Selected()
+	if !ok || selection.Model != predict.ModelHoltWinters || selection.Season != 4 {
+		t.Fatalf("Expected Holt-Winters with a season of 4, got %+v (%v)", selection, ok)
+	}
+
+	for h, got := range f.PredictAhead(4) {
+		if want := value(48 + h); math.Abs(got-want) > 0.5 {
+			t.Errorf("Step %d: expected about %.2f, got %.2f", h+1, want, got)
+		}
+	}
+}
+
+func TestAutoSelectLevel(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	f := predict.NewForecaster(predict.WithAutoSelect(20, 10))
+	for range 80 {
+		feed(f, 100+rng.NormFloat64())
+	}
+
+	selection, ok := f.Selected()
+	if !ok {
+		t.Fatal("Expected a selection")
+	}
+	if selection.MAE > 2 {
+		t.Errorf("Expected a small holdout error for level traffic, got %.2f with %v", selection.MAE, selection.Model)
+	}
+}
+
+func TestAutoSelectNeedsHistory(t *testing.T) {
+	f := predict.NewForecaster(predict.WithAutoSelect(10, 1))
+	for i := range 12 {
+		feed(f, float64(i))
+	}
+
+	if _, ok := f.Selected(); ok {
+		t.Error("Expected no selection before the holdout can be backtested")
+	}
+
+	feed(f, 12)
+	if _, ok := f.SelectModel(); !ok {
+		t.Error("Expected a selection once enough history exists")
+	}
+}
+
+func TestAutoSelectIntervalAndAccuracy(t *testing.T) {
+	f := predict.NewForecaster(predict.WithAutoSelect(10, 5))
+	for i := range 60 {
+		feed(f, 10+3*float64(i)+float64(i%2))
+	}
+
+	interval := f.PredictInterval(0.8)
+	if next := f.PredictNext(); interval.Value != next {
+		t.Errorf("Expected the interval to centre on PredictNext %.2f, got %.2f", next, interval.Value)
+	}
+	if width := interval.Upper - interval.Lower; width <= 0 || width > 5 {
+		t.Errorf("Expected a narrow band around the selected trend, got %+v", interval)
+	}
+
+	//smoothing would lag the trend by far more than the selected model's error
+	if acc := f.Accuracy(); acc.MAE > 5 {
+		t.Errorf("Expected accuracy to score the selected model, got %+v", acc)
+	}
+}
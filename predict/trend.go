@@ -0,0 +1,141 @@
+package predict
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// pairwise slopes Theil-Sen computes before it switches to sampling
+const maxTheilSenPairs = 100000
+
+type TrendMethod int
+
+const (
+	//TrendLeastSquares fits the line minimising squared error
+	TrendLeastSquares TrendMethod = iota
+
+	//TrendTheilSen takes the median of pairwise slopes, so spikes and outages barely move it
+	TrendTheilSen
+)
+
+// Trend is a straight line through the history
+type Trend struct {
+	//change in value per second
+	Slope float64
+
+	//value at Origin
+	Intercept float64
+
+	Origin time.Time
+}
+
+// At projects the trend to t
+func (t Trend) At(at time.Time) float64 {
+	return t.Intercept + t.Slope*at.Sub(t.Origin).Seconds()
+}
+
+// TimeToReach returns how long after from the trend reaches value, e.g. when growth hits
+// MaxLimit. It reports false when the trend is flat or heading away from value.
+func (t Trend) TimeToReach(value float64, from time.Time) (time.Duration, bool) {
+	gap := value - t.At(from)
+	if gap == 0 {
+		return 0, true
+	}
+	if t.Slope == 0 || math.Signbit(gap) != math.Signbit(t.Slope) {
+		return 0, false
+	}
+
+	seconds := gap / t.Slope
+	if seconds > float64(math.MaxInt64)/float64(time.Second) {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// Trend fits a line to the history, or the completed buckets when bucketing. It reports false
+// until there are two points at different times.
+func (f *Forecaster) Trend(method TrendMethod) (Trend, bool) {
+	//fitted on a copy so the forecaster is not locked while fitting
+	f.mu.RLock()
+	history := append([]DataPoint(nil), f.completed()...)
+	f.mu.RUnlock()
+	if len(history) < 2 {
+		return Trend{}, false
+	}
+
+	origin := history[0].Timestamp
+	xs := make([]float64, len(history))
+	ys := make([]float64, len(history))
+	for i, point := range history {
+		xs[i] = point.Timestamp.Sub(origin).Seconds()
+		ys[i] = point.Value
+	}
+	if xs[len(xs)-1] == 0 {
+		return Trend{}, false
+	}
+
+	var slope, intercept float64
+	switch method {
+	case TrendTheilSen:
+		slope, intercept = theilSen(xs, ys)
+	default:
+		slope, intercept = leastSquares(xs, ys)
+	}
+
+	return Trend{Slope: slope, Intercept: intercept, Origin: origin}, true
+}
+
+func leastSquares(xs, ys []float64) (slope, intercept float64) {
+	n := float64(len(xs))
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXY += xs[i] * ys[i]
+		sumXX += xs[i] * xs[i]
+	}
+
+	if denom := n*sumXX - sumX*sumX; denom != 0 {
+		slope = (n*sumXY - sumX*sumY) / denom
+	}
+	intercept = (sumY - slope*sumX) / n
+	return slope, intercept
+}
+
+// theilSen uses every pair of points up to maxTheilSenPairs, and a fixed random sample of
+// that many pairs beyond, which keeps long histories fast without losing robustness
+func theilSen(xs, ys []float64) (slope, intercept float64) {
+	n := len(xs)
+	var slopes []float64
+
+	if n*(n-1)/2 <= maxTheilSenPairs {
+		slopes = make([]float64, 0, n*(n-1)/2)
+		for i := range xs {
+			for j := i + 1; j < n; j++ {
+				if dx := xs[j] - xs[i]; dx != 0 {
+					slopes = append(slopes, (ys[j]-ys[i])/dx)
+				}
+			}
+		}
+	} else {
+		rng := rand.New(rand.NewSource(int64(n)))
+		slopes = make([]float64, 0, maxTheilSenPairs)
+		for range maxTheilSenPairs {
+			i, j := rng.Intn(n), rng.Intn(n)
+			if dx := xs[j] - xs[i]; dx != 0 {
+				slopes = append(slopes, (ys[j]-ys[i])/dx)
+			}
+		}
+	}
+	if len(slopes) == 0 {
+		return 0, median(ys)
+	}
+	slope = median(slopes)
+
+	offsets := make([]float64, len(xs))
+	for i := range xs {
+		offsets[i] = ys[i] - slope*xs[i]
+	}
+	return slope, median(offsets)
+}
@@ -0,0 +1,121 @@
+package predict_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+func TestTrendLeastSquares(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster()
+	for day := range 30 {
+		f.AddDataPointAt(start.Add(time.Duration(day)*24*time.Hour), 100+10*float64(day))
+	}
+
+	trend, ok := f.Trend(predict.TrendLeastSquares)
+	if !ok {
+		t.Fatal("Expected a trend")
+	}
+
+	perDay := trend.Slope * (24 * time.Hour).Seconds()
+	if math.Abs(perDay-10) > 1e-6 || math.Abs(trend.Intercept-100) > 1e-6 {
+		t.Errorf("Expected 10 per day from 100, got %.4f per day from %.4f", perDay, trend.Intercept)
+	}
+
+	if got := trend.At(start.Add(40 * 24 * time.Hour)); math.Abs(got-500) > 1e-6 {
+		t.Errorf("Expected 500 after 40 days, got %.4f", got)
+	}
+
+	last := start.Add(29 * 24 * time.Hour)
+	wait, ok := trend.TimeToReach(1000, last)
+	if !ok || math.Abs(wait.Hours()/24-61) > 1e-6 {
+		t.Errorf("Expected to reach 1000 in 61 days, got %v (%v)", wait, ok)
+	}
+	if _, ok := trend.TimeToReach(50, last); ok {
+		t.Error("A rising trend should never reach a lower value")
+	}
+}
+
+func TestTrendTheilSenIgnoresOutliers(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster()
+	for i := range 50 {
+		value := 20 + 2*float64(i)
+		if i%10 == 9 {
+			value = 1000
+		}
+		f.AddDataPointAt(start.Add(time.Duration(i)*time.Minute), value)
+	}
+
+	robust, _ := f.Trend(predict.TrendTheilSen)
+	naive, _ := f.Trend(predict.TrendLeastSquares)
+
+	perMinute := robust.Slope * 60
+	if math.Abs(perMinute-2) > 1e-6 || math.Abs(robust.Intercept-20) > 1e-6 {
+		t.Errorf("Expected 2 per minute from 20, got %.4f per minute from %.4f", perMinute, robust.Intercept)
+	}
+	if math.Abs(naive.Slope*60-2) < 1e-3 && math.Abs(naive.Intercept-20) < 1e-3 {
+		t.Error("Expected the spikes to pull the least-squares fit")
+	}
+}
+
+func TestTrendNeedsTwoTimes(t *testing.T) {
+	f := predict.NewForecaster()
+	if _, ok := f.Trend(predict.TrendLeastSquares); ok {
+		t.Error("Expected no trend without history")
+	}
+
+	now := time.Now()
+	f.AddDataPointAt(now, 1)
+	f.AddDataPointAt(now, 2)
+	if _, ok := f.Trend(predict.TrendTheilSen); ok {
+		t.Error("Expected no trend when every point has the same time")
+	}
+}
+
+func TestTrendTheilSenLongHistory(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	//a week of minute buckets, one in twenty an outage
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 7*24*time.Hour))
+	for i := range 7 * 24 * 60 {
+		value := 100 + 0.01*float64(i)
+		if i%20 == 0 {
+			value = 0
+		}
+		f.AddDataPointAt(start.Add(time.Duration(i)*time.Minute), value)
+	}
+
+	began := time.Now()
+	trend, ok := f.Trend(predict.TrendTheilSen)
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("Expected a week of buckets to fit quickly, took %v", elapsed)
+	}
+
+	if !ok || math.Abs(trend.Slope*60-0.01) > 1e-4 || math.Abs(trend.Intercept-100) > 0.5 {
+		t.Errorf("Expected 0.01 per minute from 100, got %.5f per minute from %.2f", trend.Slope*60, trend.Intercept)
+	}
+}
+
+func TestTrendIgnoresFillingBucket(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	f := predict.NewForecaster(predict.WithBuckets(time.Minute, 0))
+
+	//flat traffic of 60 a minute, then the first request of a new minute
+	for minute := range 20 {
+		for second := range 60 {
+			f.AddDataPointAt(start.Add(time.Duration(minute)*time.Minute+time.Duration(second)*time.Second), 1)
+		}
+	}
+	f.AddDataPointAt(start.Add(20*time.Minute), 1)
+
+	for _, method := range []predict.TrendMethod{predict.TrendLeastSquares, predict.TrendTheilSen} {
+		trend, ok := f.Trend(method)
+		if !ok || trend.Slope != 0 || trend.Intercept != 60 {
+			t.Errorf("Method %d: expected a flat trend at 60, got %+v (%v)", method, trend, ok)
+		}
+	}
+}
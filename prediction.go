@@ -0,0 +1,163 @@
+package adaptlimit
+
+import (
+	"math"
+
+	"github.com/estavadormir/adaptlimit/config"
+	"github.com/estavadormir/adaptlimit/predict"
+)
+
+// forecasts need a few intervals of demand before they are trusted
+const minPredictionHistory = 3
+
+// number of realised forecasts a forecastScore averages over
+const forecastScoreWindow = 20
+
+// forecastScore compares the forecasts the limiter acted on with the demand that followed
+type forecastScore struct {
+	//forecasts for intervals still to come, oldest first
+	pending []float64
+
+	//absolute percentage errors of the most recent realised forecasts
+	errors []float64
+}
+
+// realise scores the forecast made horizon intervals ago against the demand that arrived
+func (s *forecastScore) realise(actual float64, horizon int) {
+	if len(s.pending) < horizon {
+		return
+	}
+
+	forecast := s.pending[0]
+	s.pending = s.pending[1:]
+	if actual == 0 {
+		return
+	}
+
+	s.errors = append(s.errors, math.Abs(actual-forecast)/actual)
+	if len(s.errors) > forecastScoreWindow {
+		s.errors = s.errors[len(s.errors)-forecastScoreWindow:]
+	}
+}
+
+func (s *forecastScore) expect(forecast float64) {
+	s.pending = append(s.pending, forecast)
+}
+
+// mape returns the mean absolute percentage error and the number of forecasts it covers
+func (s *forecastScore) mape() (float64, int) {
+	if len(s.errors) == 0 {
+		return 0, 0
+	}
+
+	sum := 0.0
+	for _, e := range s.errors {
+		sum += e
+	}
+	return sum / float64(len(s.errors)), len(s.errors)
+}
+
+// predictGlobalGrowth feeds total demand to the global forecaster and returns the expected
+// ratio of future to current demand. It must be called with l.mu held, from the adjuster.
+func (l *limiter) predictGlobalGrowth() (float64, bool) {
+	if l.config.Prediction != config.PredictionGlobal {
+		return 0, false
+	}
+
+	total := 0.0
+	for _, limit := range l.limits {
+		limit.mu.Lock()
+		total += float64(limit.demandCount)
+		limit.mu.Unlock()
+	}
+
+	forecast, ok := l.forecast(l.forecaster, &l.score, total)
+	if !ok || total == 0 {
+		return 0, false
+	}
+
+	return forecast / total, true
+}
+
+// predictDemand returns the number of requests limit is expected to see per adjust interval
+// at the prediction horizon, records the demand with the key's forecaster, and resets the demand
+// counter. It must be called with limit.mu held.
+func (l *limiter) predictDemand(limit *keyLimit, growth float64, hasGrowth bool) (float64, bool) {
+	demand := float64(limit.demandCount)
+	limit.demandCount = 0
+
+	if l.config.Prediction == config.PredictionPerKey {
+		return l.forecast(limit.forecaster, &limit.score, demand)
+	}
+
+	//the key's forecaster may still be kept for anomaly detection
+	if limit.forecaster != nil {
+		limit.forecaster.AddDataPoint(demand)
+	}
+
+	if l.config.Prediction == config.PredictionGlobal {
+		return demand * growth, hasGrowth
+	}
+	return 0, false
+}
+
+// forecast records demand, scores the forecast made for it, and returns demand at the horizon
+// once forecaster has enough history and its recent forecasts were accurate enough to act on
+func (l *limiter) forecast(forecaster *predict.Forecaster, score *forecastScore, demand float64) (float64, bool) {
+	forecaster.AddDataPoint(demand)
+	score.realise(demand, l.horizon())
+
+	if forecaster.Len() < minPredictionHistory {
+		return 0, false
+	}
+
+	forecast := l.forecastAhead(forecaster.PredictAhead(l.horizon()))
+	score.expect(forecast)
+
+	return forecast, l.trusted(score)
+}
+
+// prescale moves refillRate towards the predicted demand plus headroom. It only raises
+// the rate while the key is healthy, and lowers it by at most 10% per adjustment.
+func (l *limiter) prescale(refillRate, predicted float64, healthy bool) float64 {
+	headroom := l.config.PredictionHeadroom
+	if headroom <= 0 {
+		headroom = 1
+	}
+
+	target := predicted / l.adjustInterval.Seconds() * headroom
+
+	if target > refillRate && healthy {
+		return target
+	}
+
+	if target < refillRate {
+		return max(target, refillRate*0.9)
+	}
+
+	return refillRate
+}
+
+// trusted reports whether the forecasts scored so far were accurate enough to act on
+func (l *limiter) trusted(score *forecastScore) bool {
+	if l.config.PredictionMaxError <= 0 {
+		return true
+	}
+
+	mape, n := score.mape()
+	return n < minPredictionHistory || mape <= l.config.PredictionMaxError
+}
+
+func (l *limiter) horizon() int {
+	if l.config.PredictionHorizon < 1 {
+		return 1
+	}
+	return l.config.PredictionHorizon
+}
+
+func (l *limiter) forecastAhead(forecast []float64) float64 {
+	if len(forecast) == 0 {
+		return 0
+	}
+	return max(0, forecast[len(forecast)-1])
+}
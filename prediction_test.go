@@ -0,0 +1,136 @@
+package adaptlimit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/estavadormir/adaptlimit/config"
+	"github.com/estavadormir/adaptlimit/metrics"
+)
+
+func newPredictingLimiter(t *testing.T, mode config.PredictionMode) *limiter {
+	t.Helper()
+
+	//the tests drive adjustLimits by hand well within the first tick
+	cfg := config.DefaultConfig().
+		WithInitialLimit(10).
+		WithMinLimit(1).
+		WithAdjustInterval(time.Second).
+		WithCollector(metrics.NewFake(metrics.Sample{CPU: 0.5, Memory: 0.5})).
+		WithPrediction(mode, 1, 1.0)
+
+	l := New(cfg).(*limiter)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func (l *limiter) refillRateOf(key string) float64 {
+	limit := l.getOrCreateLimit(key)
+	limit.mu.Lock()
+	defer limit.mu.Unlock()
+	return limit.refillRate
+}
+
+func TestPredictionPerKeyRampsAhead(t *testing.T) {
+	l := newPredictingLimiter(t, config.PredictionPerKey)
+
+	for _, demand := range []int{10, 20, 30, 40} {
+		for range demand {
+			l.Allow("ramping")
+		}
+		l.adjustLimits()
+	}
+
+	if rate := l.refillRateOf("ramping"); rate <= 40 {
+		t.Errorf("Refill rate should be raised ahead of the growing demand, got %.2f", rate)
+	}
+}
+
+func TestPredictionPerKeyEasesDown(t *testing.T) {
+	l := newPredictingLimiter(t, config.PredictionPerKey)
+
+	for range 3 {
+		for range 5 {
+			l.Allow("quiet")
+		}
+		l.adjustLimits()
+	}
+
+	before := l.refillRateOf("quiet")
+	l.adjustLimits()
+	after := l.refillRateOf("quiet")
+
+	if after >= before || after < before*0.9-1e-9 {
+		t.Errorf("Refill rate should ease down by at most 10%% per adjustment, went from %.2f to %.2f", before, after)
+	}
+}
+
+func TestPredictionGlobal(t *testing.T) {
+	l := newPredictingLimiter(t, config.PredictionGlobal)
+
+	for _, demand := range []int{10, 20, 30, 40} {
+		for range demand {
+			l.Allow("a")
+		}
+		for range demand / 2 {
+			l.Allow("b")
+		}
+		l.adjustLimits()
+	}
+
+	a, b := l.refillRateOf("a"), l.refillRateOf("b")
+	if a <= 40 || b <= 20 {
+		t.Errorf("Both keys should be raised ahead of total demand, got a=%.2f b=%.2f", a, b)
+	}
+	if a <= b {
+		t.Errorf("The key with more demand should get the larger share, got a=%.2f b=%.2f", a, b)
+	}
+}
+
+func TestForecastScore(t *testing.T) {
+	var score forecastScore
+
+	//forecasts two intervals ahead are scored against the demand two intervals later
+	for _, demand := range []float64{10, 10, 10, 10} {
+		score.realise(demand, 2)
+		score.expect(10)
+	}
+	if mape, n := score.mape(); n != 2 || mape != 0 {
+		t.Errorf("Expected 2 perfect forecasts, got MAPE %.2f over %d", mape, n)
+	}
+
+	score.realise(20, 2)
+	if mape, n := score.mape(); n != 3 || math.Abs(mape-0.5/3) > 1e-9 {
+		t.Errorf("Expected MAPE %.4f over 3, got %.4f over %d", 0.5/3, mape, n)
+	}
+}
+
+func TestPredictionMaxError(t *testing.T) {
+	l := newPredictingLimiter(t, config.PredictionPerKey)
+
+	for i := range 12 {
+		for range 2 + 40*(i%2) {
+			l.Allow("erratic")
+		}
+		for range 20 {
+			l.Allow("steady")
+		}
+		l.adjustLimits()
+	}
+
+	erratic, steady := l.getOrCreateLimit("erratic"), l.getOrCreateLimit("steady")
+	if !l.trusted(&erratic.score) {
+		t.Error("Every forecast should be trusted without a maximum error")
+	}
+
+	l.config.PredictionMaxError = 0.1
+	if l.trusted(&erratic.score) {
+		mape, _ := erratic.score.mape()
+		t.Errorf("Forecasts of erratic demand should not be trusted, MAPE %.2f", mape)
+	}
+	if !l.trusted(&steady.score) {
+		mape, _ := steady.score.mape()
+		t.Errorf("Forecasts of steady demand should be trusted, MAPE %.2f", mape)
+	}
+}